@@ -0,0 +1,261 @@
+package data
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+
+	"github.com/Lyx52/micrograd-in-go.git/nn"
+)
+
+func uniform(random *rand.Rand, low float64, high float64) float64 {
+	return low + random.Float64()*(high-low)
+}
+
+// Transforms built from invalid parameters fail on every call, so they still compose inline
+func invalidTransform(err error) Transform {
+	return func(random *rand.Rand, tensor *nn.Tensor) (*nn.Tensor, error) {
+		return nil, err
+	}
+}
+
+func RandomCrop(height int, width int, padding int) Transform {
+	if height <= 0 || width <= 0 || padding < 0 {
+		return invalidTransform(fmt.Errorf("random crop %dx%d with padding %d must be positive", height, width, padding))
+	}
+
+	return func(random *rand.Rand, tensor *nn.Tensor) (*nn.Tensor, error) {
+		img, err := toRaster(tensor)
+		if err != nil {
+			return nil, err
+		}
+
+		rangeY := img.height + 2*padding - height + 1
+		rangeX := img.width + 2*padding - width + 1
+		if rangeY <= 0 || rangeX <= 0 {
+			return nil, fmt.Errorf("crop %dx%d does not fit into padded image %dx%d", height, width, img.height+2*padding, img.width+2*padding)
+		}
+
+		top := random.Intn(rangeY) - padding
+		left := random.Intn(rangeX) - padding
+		result := newRasterLike(img, height, width)
+		for channel := 0; channel < img.channels; channel++ {
+			for y := 0; y < height; y++ {
+				for x := 0; x < width; x++ {
+					result.set(channel, y, x, img.at(channel, top+y, left+x))
+				}
+			}
+		}
+
+		return result.tensor(), nil
+	}
+}
+
+func RandomAffine(degrees float64, translate float64, scaleMin float64, scaleMax float64, shear float64) Transform {
+	if scaleMin <= 0 || scaleMin > scaleMax {
+		return invalidTransform(fmt.Errorf("random affine scale range [%v, %v] must be positive", scaleMin, scaleMax))
+	}
+
+	return func(random *rand.Rand, tensor *nn.Tensor) (*nn.Tensor, error) {
+		img, err := toRaster(tensor)
+		if err != nil {
+			return nil, err
+		}
+
+		angle := uniform(random, -degrees, degrees) * math.Pi / 180
+		shearAngle := uniform(random, -shear, shear) * math.Pi / 180
+		translateX := uniform(random, -translate, translate) * float64(img.width)
+		translateY := uniform(random, -translate, translate) * float64(img.height)
+		scale := uniform(random, scaleMin, scaleMax)
+
+		// Forward transform is scale * rotation * shear, sampling needs its inverse
+		sin, cos, tan := math.Sin(angle), math.Cos(angle), math.Tan(shearAngle)
+		a, b := scale*cos, scale*(cos*tan-sin)
+		c, d := scale*sin, scale*(sin*tan+cos)
+		det := a*d - b*c
+		if math.Abs(det) < 1e-12 {
+			return nil, fmt.Errorf("affine transform is not invertible (scale=%v, shear=%v)", scale, shearAngle)
+		}
+
+		centerX, centerY := float64(img.width-1)/2, float64(img.height-1)/2
+		result := img.remap(func(y int, x int) (float64, float64) {
+			u := float64(x) - centerX - translateX
+			v := float64(y) - centerY - translateY
+
+			return (-c*u+a*v)/det + centerY, (d*u-b*v)/det + centerX
+		})
+
+		return result.tensor(), nil
+	}
+}
+
+func RandomHorizontalFlip(probability float64) Transform {
+	return func(random *rand.Rand, tensor *nn.Tensor) (*nn.Tensor, error) {
+		img, err := toRaster(tensor)
+		if err != nil {
+			return nil, err
+		}
+
+		if random.Float64() >= probability {
+			return tensor, nil
+		}
+
+		result := newRasterLike(img, img.height, img.width)
+		for channel := 0; channel < img.channels; channel++ {
+			for y := 0; y < img.height; y++ {
+				for x := 0; x < img.width; x++ {
+					result.set(channel, y, x, img.at(channel, y, img.width-1-x))
+				}
+			}
+		}
+
+		return result.tensor(), nil
+	}
+}
+
+func GaussianNoise(std float64) Transform {
+	if std < 0 {
+		return invalidTransform(fmt.Errorf("gaussian noise std %v is negative", std))
+	}
+
+	return func(random *rand.Rand, tensor *nn.Tensor) (*nn.Tensor, error) {
+		img, err := toRaster(tensor)
+		if err != nil {
+			return nil, err
+		}
+
+		result := img.clone()
+		for i := range result.values {
+			result.values[i] += random.NormFloat64() * std
+		}
+
+		return result.tensor(), nil
+	}
+}
+
+func RandomErasing(probability float64, scaleMin float64, scaleMax float64, ratioMin float64, ratioMax float64, value float64) Transform {
+	if scaleMin <= 0 || scaleMin > scaleMax || scaleMax > 1 {
+		return invalidTransform(fmt.Errorf("random erasing scale range [%v, %v] must lie within (0, 1]", scaleMin, scaleMax))
+	}
+
+	if ratioMin <= 0 || ratioMin > ratioMax {
+		return invalidTransform(fmt.Errorf("random erasing ratio range [%v, %v] must be positive", ratioMin, ratioMax))
+	}
+
+	return func(random *rand.Rand, tensor *nn.Tensor) (*nn.Tensor, error) {
+		img, err := toRaster(tensor)
+		if err != nil {
+			return nil, err
+		}
+
+		if random.Float64() >= probability {
+			return tensor, nil
+		}
+
+		area := float64(img.height * img.width)
+		for attempt := 0; attempt < 10; attempt++ {
+			target := area * uniform(random, scaleMin, scaleMax)
+			ratio := math.Exp(uniform(random, math.Log(ratioMin), math.Log(ratioMax)))
+			height := int(math.Round(math.Sqrt(target * ratio)))
+			width := int(math.Round(math.Sqrt(target / ratio)))
+			if height <= 0 || width <= 0 || height >= img.height || width >= img.width {
+				continue
+			}
+
+			top := random.Intn(img.height - height + 1)
+			left := random.Intn(img.width - width + 1)
+			result := img.clone()
+			for channel := 0; channel < img.channels; channel++ {
+				for y := top; y < top+height; y++ {
+					for x := left; x < left+width; x++ {
+						result.set(channel, y, x, value)
+					}
+				}
+			}
+
+			return result.tensor(), nil
+		}
+
+		return tensor, nil
+	}
+}
+
+func gaussianKernel(sigma float64) []float64 {
+	radius := int(math.Ceil(3 * sigma))
+	kernel := make([]float64, 2*radius+1)
+	sum := 0.0
+	for i := range kernel {
+		offset := float64(i - radius)
+		kernel[i] = math.Exp(-offset * offset / (2 * sigma * sigma))
+		sum += kernel[i]
+	}
+
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+
+	return kernel
+}
+
+func gaussianBlur(field []float64, height int, width int, sigma float64) []float64 {
+	kernel := gaussianKernel(sigma)
+	radius := len(kernel) / 2
+	horizontal := make([]float64, len(field))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			sum := 0.0
+			for k := range kernel {
+				if source := x + k - radius; source >= 0 && source < width {
+					sum += kernel[k] * field[y*width+source]
+				}
+			}
+
+			horizontal[y*width+x] = sum
+		}
+	}
+
+	result := make([]float64, len(field))
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			sum := 0.0
+			for k := range kernel {
+				if source := y + k - radius; source >= 0 && source < height {
+					sum += kernel[k] * horizontal[source*width+x]
+				}
+			}
+
+			result[y*width+x] = sum
+		}
+	}
+
+	return result
+}
+
+func ElasticDistortion(alpha float64, sigma float64) Transform {
+	if sigma <= 0 {
+		return invalidTransform(fmt.Errorf("elastic distortion sigma %v must be positive", sigma))
+	}
+
+	return func(random *rand.Rand, tensor *nn.Tensor) (*nn.Tensor, error) {
+		img, err := toRaster(tensor)
+		if err != nil {
+			return nil, err
+		}
+
+		displacementX := make([]float64, img.height*img.width)
+		displacementY := make([]float64, img.height*img.width)
+		for i := range displacementX {
+			displacementX[i] = uniform(random, -1, 1)
+			displacementY[i] = uniform(random, -1, 1)
+		}
+
+		displacementX = gaussianBlur(displacementX, img.height, img.width, sigma)
+		displacementY = gaussianBlur(displacementY, img.height, img.width, sigma)
+		result := img.remap(func(y int, x int) (float64, float64) {
+			i := y*img.width + x
+			return float64(y) + alpha*displacementY[i], float64(x) + alpha*displacementX[i]
+		})
+
+		return result.tensor(), nil
+	}
+}
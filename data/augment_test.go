@@ -0,0 +1,56 @@
+package data
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+
+	"github.com/Lyx52/micrograd-in-go.git/nn"
+)
+
+func TestTransformsKeepShape(t *testing.T) {
+	transforms := map[string]Transform{
+		"RandomCrop":           RandomCrop(6, 5, 2),
+		"RandomAffine":         RandomAffine(15, 0.1, 0.9, 1.1, 5),
+		"RandomHorizontalFlip": RandomHorizontalFlip(1),
+		"GaussianNoise":        GaussianNoise(0.1),
+		"RandomErasing":        RandomErasing(1, 0.1, 0.3, 0.5, 2, 0),
+		"ElasticDistortion":    ElasticDistortion(2, 1.5),
+		"Compose":              Compose(RandomCrop(6, 5, 1), GaussianNoise(0.1), RandomHorizontalFlip(0.5)),
+	}
+
+	random := rand.New(rand.NewSource(1))
+	for _, shape := range [][]int{{6, 5}, {3, 6, 5}} {
+		for name, transform := range transforms {
+			input := nn.NewTensorEmpty(nn.GetTotalElements(shape)).Reshape(slices.Clone(shape)...)
+			for i := range input.Backing.Backing {
+				input.Backing.Backing[i] = random.Float64()
+			}
+
+			output, err := transform(random, input)
+			if err != nil {
+				t.Fatalf("%s on %v: %v", name, shape, err)
+			}
+
+			if !slices.Equal(output.Shape(), shape) {
+				t.Errorf("%s changed shape %v to %v", name, shape, output.Shape())
+			}
+		}
+	}
+}
+
+func TestTransformsRejectInvalidParameters(t *testing.T) {
+	transforms := map[string]Transform{
+		"RandomCrop":        RandomCrop(0, 5, 0),
+		"RandomAffine":      RandomAffine(0, 0, 1.2, 1.1, 0),
+		"GaussianNoise":     GaussianNoise(-1),
+		"RandomErasing":     RandomErasing(1, 0.1, 0.3, 0, 2, 0),
+		"ElasticDistortion": ElasticDistortion(2, 0),
+	}
+
+	for name, transform := range transforms {
+		if _, err := Compose(transform)(rand.New(rand.NewSource(1)), nn.NewTensorEmpty(30).Reshape(6, 5)); err == nil {
+			t.Errorf("%s accepted invalid parameters", name)
+		}
+	}
+}
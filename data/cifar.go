@@ -0,0 +1,248 @@
+package data
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"os"
+
+	"github.com/Lyx52/micrograd-in-go.git/nn"
+)
+
+const (
+	CifarChannels = 3
+	CifarHeight   = 32
+	CifarWidth    = 32
+	cifarPixels   = CifarChannels * CifarHeight * CifarWidth
+)
+
+type CifarVariant int
+
+const (
+	Cifar10 CifarVariant = iota
+	Cifar100
+)
+
+func (variant CifarVariant) labelBytes() int {
+	if variant == Cifar100 {
+		return 2
+	}
+
+	return 1
+}
+
+func (variant CifarVariant) recordSize() int {
+	return variant.labelBytes() + cifarPixels
+}
+
+func (variant CifarVariant) Classes(coarse bool) int {
+	if variant == Cifar10 {
+		return 10
+	}
+
+	if coarse {
+		return 20
+	}
+
+	return 100
+}
+
+type CifarRecord struct {
+	Image       *nn.Tensor
+	Label       int
+	CoarseLabel int
+}
+
+func decodeCifarRecord(variant CifarVariant, record []byte) *CifarRecord {
+	result := &CifarRecord{Label: int(record[0]), CoarseLabel: -1}
+	if variant == Cifar100 {
+		result.CoarseLabel = int(record[0])
+		result.Label = int(record[1])
+	}
+
+	pixels := record[variant.labelBytes():]
+	values := make([]float64, cifarPixels)
+	for i := range values {
+		values[i] = float64(pixels[i])
+	}
+
+	result.Image = nn.NewTensorFromArray(values).Reshape(CifarChannels, CifarHeight, CifarWidth)
+	return result
+}
+
+type CifarReader struct {
+	variant CifarVariant
+	file    *os.File
+	reader  *bufio.Reader
+	buffer  []byte
+}
+
+func OpenCifar(filePath string, variant CifarVariant) (*CifarReader, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CifarReader{
+		variant: variant,
+		file:    file,
+		reader:  bufio.NewReader(file),
+		buffer:  make([]byte, variant.recordSize()),
+	}, nil
+}
+
+func (reader *CifarReader) Next() (*CifarRecord, error) {
+	_, err := io.ReadFull(reader.reader, reader.buffer)
+	if errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, fmt.Errorf("truncated cifar record in %s", reader.file.Name())
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeCifarRecord(reader.variant, reader.buffer), nil
+}
+
+func (reader *CifarReader) Records() iter.Seq2[*CifarRecord, error] {
+	return func(yield func(*CifarRecord, error) bool) {
+		for {
+			record, err := reader.Next()
+			if errors.Is(err, io.EOF) {
+				return
+			}
+
+			if !yield(record, err) || err != nil {
+				return
+			}
+		}
+	}
+}
+
+func (reader *CifarReader) Close() error {
+	return reader.file.Close()
+}
+
+func ReadCifar(filePath string, variant CifarVariant) ([]*CifarRecord, error) {
+	reader, err := OpenCifar(filePath, variant)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+
+	result := make([]*CifarRecord, 0)
+	for record, err := range reader.Records() {
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, record)
+	}
+
+	return result, nil
+}
+
+type cifarFile struct {
+	file    *os.File
+	records int
+}
+
+type CifarDataset struct {
+	Coarse  bool
+	variant CifarVariant
+	files   []cifarFile
+	length  int
+}
+
+func OpenCifarDataset(variant CifarVariant, filePaths ...string) (*CifarDataset, error) {
+	dataset := &CifarDataset{variant: variant}
+	for _, filePath := range filePaths {
+		file, err := os.Open(filePath)
+		if err != nil {
+			dataset.Close()
+			return nil, err
+		}
+
+		info, err := file.Stat()
+		if err != nil {
+			file.Close()
+			dataset.Close()
+			return nil, err
+		}
+
+		if info.Size()%int64(variant.recordSize()) != 0 {
+			file.Close()
+			dataset.Close()
+			return nil, fmt.Errorf("%s is not a cifar batch file, size %d is not a multiple of %d", filePath, info.Size(), variant.recordSize())
+		}
+
+		records := int(info.Size() / int64(variant.recordSize()))
+		dataset.files = append(dataset.files, cifarFile{file: file, records: records})
+		dataset.length += records
+	}
+
+	return dataset, nil
+}
+
+func (dataset *CifarDataset) Len() int {
+	return dataset.length
+}
+
+func (dataset *CifarDataset) Record(index int) (*CifarRecord, error) {
+	if index < 0 || index >= dataset.length {
+		return nil, fmt.Errorf("cifar index %d out of range [0, %d)", index, dataset.length)
+	}
+
+	for _, file := range dataset.files {
+		if index >= file.records {
+			index -= file.records
+			continue
+		}
+
+		// ReadAt keeps Record safe to call from several loader workers at once
+		buffer := make([]byte, dataset.variant.recordSize())
+		if _, err := file.file.ReadAt(buffer, int64(index)*int64(len(buffer))); err != nil {
+			return nil, err
+		}
+
+		return decodeCifarRecord(dataset.variant, buffer), nil
+	}
+
+	return nil, fmt.Errorf("cifar index %d out of range [0, %d)", index, dataset.length)
+}
+
+func (dataset *CifarDataset) Get(index int) (Sample, error) {
+	record, err := dataset.Record(index)
+	if err != nil {
+		return Sample{}, err
+	}
+
+	label := record.Label
+	if dataset.Coarse && dataset.variant == Cifar100 {
+		label = record.CoarseLabel
+	}
+
+	classes := dataset.variant.Classes(dataset.Coarse)
+	if label >= classes {
+		return Sample{}, fmt.Errorf("cifar label %d out of range for %d classes", label, classes)
+	}
+
+	target := nn.NewTensorEmpty(classes)
+	target.Backing.Backing[label] = 1.0
+
+	return Sample{X: record.Image, Y: target}, nil
+}
+
+func (dataset *CifarDataset) Close() error {
+	var result error
+	for _, file := range dataset.files {
+		if err := file.file.Close(); err != nil {
+			result = err
+		}
+	}
+
+	dataset.files = nil
+	return result
+}
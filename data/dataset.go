@@ -0,0 +1,52 @@
+package data
+
+import (
+	"fmt"
+	"math/rand"
+
+	"github.com/Lyx52/micrograd-in-go.git/nn"
+)
+
+type Sample struct {
+	X *nn.Tensor
+	Y *nn.Tensor
+}
+
+type Dataset interface {
+	Len() int
+	Get(index int) (Sample, error)
+}
+
+type RandomDataset interface {
+	Dataset
+	GetWithRandom(index int, random *rand.Rand) (Sample, error)
+}
+
+type TensorDataset struct {
+	xs []*nn.Tensor
+	ys []*nn.Tensor
+}
+
+func NewTensorDataset(xs []*nn.Tensor, ys []*nn.Tensor) *TensorDataset {
+	if ys != nil && len(xs) != len(ys) {
+		panic(fmt.Sprintf("dataset features and targets differ in length %v != %v", len(xs), len(ys)))
+	}
+
+	return &TensorDataset{xs: xs, ys: ys}
+}
+
+func (dataset *TensorDataset) Len() int {
+	return len(dataset.xs)
+}
+
+func (dataset *TensorDataset) Get(index int) (Sample, error) {
+	if index < 0 || index >= len(dataset.xs) {
+		return Sample{}, fmt.Errorf("dataset index %d out of range [0, %d)", index, len(dataset.xs))
+	}
+
+	if dataset.ys == nil {
+		return Sample{X: dataset.xs[index]}, nil
+	}
+
+	return Sample{X: dataset.xs[index], Y: dataset.ys[index]}, nil
+}
@@ -0,0 +1,197 @@
+package data
+
+import (
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	_ "image/png"
+	"io/fs"
+	"math"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/Lyx52/micrograd-in-go.git/nn"
+)
+
+type ColorMode int
+
+const (
+	Grayscale ColorMode = iota
+	RGB
+)
+
+func (mode ColorMode) Channels() int {
+	if mode == RGB {
+		return 3
+	}
+
+	return 1
+}
+
+var imageExtensions = []string{".png", ".jpg", ".jpeg"}
+
+type ImageFolder struct {
+	Encoder *LabelEncoder[string]
+	Mode    ColorMode
+	Height  int
+	Width   int
+	paths   []string
+	labels  []int
+	cache   []*nn.Tensor
+}
+
+func NewImageFolder(root string, mode ColorMode, height int, width int, lazy bool) (*ImageFolder, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+
+	classes := make([]string, 0)
+	for _, entry := range entries {
+		if entry.IsDir() {
+			classes = append(classes, entry.Name())
+		}
+	}
+
+	folder := &ImageFolder{
+		Encoder: NewLabelEncoderFromClasses(classes),
+		Mode:    mode,
+		Height:  height,
+		Width:   width,
+	}
+
+	for id, class := range classes {
+		err = filepath.WalkDir(filepath.Join(root, class), func(path string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+
+			if !entry.IsDir() && slices.Contains(imageExtensions, strings.ToLower(filepath.Ext(path))) {
+				folder.paths = append(folder.paths, path)
+				folder.labels = append(folder.labels, id)
+			}
+
+			return nil
+		})
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !lazy {
+		folder.cache = make([]*nn.Tensor, len(folder.paths))
+		for i := range folder.paths {
+			folder.cache[i], err = folder.load(i)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return folder, nil
+}
+
+func (folder *ImageFolder) Len() int {
+	return len(folder.paths)
+}
+
+func (folder *ImageFolder) Path(index int) string {
+	return folder.paths[index]
+}
+
+func (folder *ImageFolder) Labels() []int {
+	return slices.Clone(folder.labels)
+}
+
+func (folder *ImageFolder) Get(index int) (Sample, error) {
+	if index < 0 || index >= len(folder.paths) {
+		return Sample{}, fmt.Errorf("image folder index %d out of range [0, %d)", index, len(folder.paths))
+	}
+
+	target := nn.NewTensorEmpty(folder.Encoder.NumClasses())
+	target.Backing.Backing[folder.labels[index]] = 1.0
+
+	// Hand out a copy so in-place transforms or gradient resets never reach the cached image
+	if folder.cache != nil {
+		cached := folder.cache[index]
+		tensor := nn.NewTensorFromArray(slices.Clone(cached.Backing.Backing)).Reshape(slices.Clone(cached.Shape())...)
+		return Sample{X: tensor, Y: target}, nil
+	}
+
+	tensor, err := folder.load(index)
+	if err != nil {
+		return Sample{}, err
+	}
+
+	return Sample{X: tensor, Y: target}, nil
+}
+
+func (folder *ImageFolder) load(index int) (*nn.Tensor, error) {
+	file, err := os.Open(folder.paths[index])
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	decoded, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", folder.paths[index], err)
+	}
+
+	img := imageToRaster(decoded, folder.Mode)
+	if folder.Height > 0 && folder.Width > 0 && (img.height != folder.Height || img.width != folder.Width) {
+		img = img.resize(folder.Height, folder.Width)
+	}
+
+	return img.tensor(), nil
+}
+
+func imageToRaster(decoded image.Image, mode ColorMode) *raster {
+	bounds := decoded.Bounds()
+	channels := mode.Channels()
+	img := &raster{
+		channels: channels,
+		height:   bounds.Dy(),
+		width:    bounds.Dx(),
+		shape:    []int{channels, bounds.Dy(), bounds.Dx()},
+		values:   make([]float64, channels*bounds.Dy()*bounds.Dx()),
+	}
+
+	for y := 0; y < img.height; y++ {
+		for x := 0; x < img.width; x++ {
+			pixel := decoded.At(bounds.Min.X+x, bounds.Min.Y+y)
+			if mode == Grayscale {
+				img.set(0, y, x, float64(color.GrayModel.Convert(pixel).(color.Gray).Y))
+				continue
+			}
+
+			r, g, b, _ := pixel.RGBA()
+			img.set(0, y, x, float64(r>>8))
+			img.set(1, y, x, float64(g>>8))
+			img.set(2, y, x, float64(b>>8))
+		}
+	}
+
+	return img
+}
+
+func (img *raster) resize(height int, width int) *raster {
+	result := newRasterLike(img, height, width)
+	scaleY := float64(img.height) / float64(height)
+	scaleX := float64(img.width) / float64(width)
+	for y := 0; y < height; y++ {
+		sourceY := math.Min(math.Max((float64(y)+0.5)*scaleY-0.5, 0), float64(img.height-1))
+		for x := 0; x < width; x++ {
+			sourceX := math.Min(math.Max((float64(x)+0.5)*scaleX-0.5, 0), float64(img.width-1))
+			for channel := 0; channel < img.channels; channel++ {
+				result.set(channel, y, x, img.bilinear(channel, sourceY, sourceX))
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,186 @@
+package data
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/Lyx52/micrograd-in-go.git/nn"
+)
+
+type UnknownLabel int
+
+const (
+	UnknownLabelError UnknownLabel = iota
+	UnknownLabelIgnore
+)
+
+type LabelEncoder[TLabel comparable] struct {
+	HandleUnknown UnknownLabel
+	classes       []TLabel
+	ids           map[TLabel]int
+}
+
+func NewLabelEncoder[TLabel comparable]() *LabelEncoder[TLabel] {
+	return &LabelEncoder[TLabel]{
+		ids: make(map[TLabel]int),
+	}
+}
+
+func NewLabelEncoderFromClasses[TLabel comparable](classes []TLabel) *LabelEncoder[TLabel] {
+	return NewLabelEncoder[TLabel]().Fit(classes)
+}
+
+func FitOrderedLabels[TLabel cmp.Ordered](labels []TLabel) *LabelEncoder[TLabel] {
+	classes := slices.Clone(labels)
+	slices.Sort(classes)
+
+	return NewLabelEncoderFromClasses(slices.Compact(classes))
+}
+
+func (encoder *LabelEncoder[TLabel]) Fit(labels []TLabel) *LabelEncoder[TLabel] {
+	for _, label := range labels {
+		if _, ok := encoder.ids[label]; !ok {
+			encoder.ids[label] = len(encoder.classes)
+			encoder.classes = append(encoder.classes, label)
+		}
+	}
+
+	return encoder
+}
+
+func (encoder *LabelEncoder[TLabel]) NumClasses() int {
+	return len(encoder.classes)
+}
+
+func (encoder *LabelEncoder[TLabel]) Classes() []TLabel {
+	return slices.Clone(encoder.classes)
+}
+
+func (encoder *LabelEncoder[TLabel]) Encode(label TLabel) (int, error) {
+	id, ok := encoder.ids[label]
+	if ok {
+		return id, nil
+	}
+
+	if encoder.HandleUnknown == UnknownLabelIgnore {
+		return -1, nil
+	}
+
+	return -1, fmt.Errorf("unknown label %v", label)
+}
+
+func (encoder *LabelEncoder[TLabel]) EncodeAll(labels []TLabel) ([]int, error) {
+	result := make([]int, len(labels))
+	var err error
+	for i := range labels {
+		result[i], err = encoder.Encode(labels[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+func (encoder *LabelEncoder[TLabel]) OneHot(label TLabel) (*nn.Tensor, error) {
+	id, err := encoder.Encode(label)
+	if err != nil {
+		return nil, err
+	}
+
+	result := nn.NewTensorEmpty(encoder.NumClasses())
+	if id >= 0 {
+		result.Backing.Backing[id] = 1.0
+	}
+
+	return result, nil
+}
+
+func (encoder *LabelEncoder[TLabel]) OneHotAll(labels []TLabel) ([]*nn.Tensor, error) {
+	result := make([]*nn.Tensor, len(labels))
+	var err error
+	for i := range labels {
+		result[i], err = encoder.OneHot(labels[i])
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return result, nil
+}
+
+func (encoder *LabelEncoder[TLabel]) Decode(id int) (TLabel, error) {
+	if id < 0 || id >= len(encoder.classes) {
+		var empty TLabel
+		return empty, fmt.Errorf("label id %d out of range [0, %d)", id, len(encoder.classes))
+	}
+
+	return encoder.classes[id], nil
+}
+
+func (encoder *LabelEncoder[TLabel]) DecodePredictions(prediction *nn.Tensor) ([]TLabel, error) {
+	width := encoder.NumClasses()
+	if width == 0 || len(prediction.Backing.Backing)%width != 0 {
+		return nil, fmt.Errorf("prediction of shape %v does not match %d classes", prediction.Shape(), width)
+	}
+
+	values := prediction.Backing.Backing
+	result := make([]TLabel, len(values)/width)
+	for i := range result {
+		row := values[i*width : (i+1)*width]
+		result[i] = encoder.classes[slices.Index(row, slices.Max(row))]
+	}
+
+	return result, nil
+}
+
+type MultiLabelBinarizer[TLabel comparable] struct {
+	Encoder *LabelEncoder[TLabel]
+}
+
+func NewMultiLabelBinarizer[TLabel comparable]() *MultiLabelBinarizer[TLabel] {
+	return &MultiLabelBinarizer[TLabel]{
+		Encoder: NewLabelEncoder[TLabel](),
+	}
+}
+
+func (binarizer *MultiLabelBinarizer[TLabel]) Fit(labelSets [][]TLabel) *MultiLabelBinarizer[TLabel] {
+	for i := range labelSets {
+		binarizer.Encoder.Fit(labelSets[i])
+	}
+
+	return binarizer
+}
+
+func (binarizer *MultiLabelBinarizer[TLabel]) Transform(labels []TLabel) (*nn.Tensor, error) {
+	result := nn.NewTensorEmpty(binarizer.Encoder.NumClasses())
+	for _, label := range labels {
+		id, err := binarizer.Encoder.Encode(label)
+		if err != nil {
+			return nil, err
+		}
+
+		if id >= 0 {
+			result.Backing.Backing[id] = 1.0
+		}
+	}
+
+	return result, nil
+}
+
+func (binarizer *MultiLabelBinarizer[TLabel]) InverseTransform(tensor *nn.Tensor, threshold float64) ([]TLabel, error) {
+	if len(tensor.Backing.Backing) != binarizer.Encoder.NumClasses() {
+		return nil, errors.New("multi-label tensor width does not match the number of classes")
+	}
+
+	result := make([]TLabel, 0)
+	for id, value := range tensor.Backing.Backing {
+		if value >= threshold {
+			result = append(result, binarizer.Encoder.classes[id])
+		}
+	}
+
+	return result, nil
+}
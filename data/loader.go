@@ -0,0 +1,241 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"math/rand"
+
+	"github.com/Lyx52/micrograd-in-go.git/nn"
+)
+
+type Batch struct {
+	X       *nn.Tensor
+	Y       *nn.Tensor
+	Mask    *nn.Tensor
+	Lengths []int
+	Indices []int
+}
+
+func (batch *Batch) Size() int {
+	return len(batch.Indices)
+}
+
+type CollateFunction func(samples []Sample) (*Batch, error)
+
+func StackCollate(samples []Sample) (*Batch, error) {
+	if len(samples) == 0 {
+		return nil, errors.New("cannot collate an empty batch")
+	}
+
+	xs := make([]*nn.Tensor, len(samples))
+	ys := make([]*nn.Tensor, 0, len(samples))
+	for i := range samples {
+		xs[i] = samples[i].X
+		if samples[i].Y != nil {
+			ys = append(ys, samples[i].Y)
+		}
+	}
+
+	if len(ys) != 0 && len(ys) != len(samples) {
+		return nil, errors.New("cannot collate a batch where only some samples have targets")
+	}
+
+	x, err := nn.Stack(xs)
+	if err != nil {
+		return nil, err
+	}
+
+	batch := &Batch{X: x}
+	if len(ys) != 0 {
+		batch.Y, err = nn.Stack(ys)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return batch, nil
+}
+
+type BatchSampler interface {
+	Len() int
+	Sample(random *rand.Rand) [][]int
+}
+
+type DataLoader struct {
+	Dataset   Dataset
+	BatchSize int
+	Shuffle   bool
+	DropLast  bool
+	Collate   CollateFunction
+	Sampler   BatchSampler
+	Workers   int
+	Prefetch  int
+	Ordered   bool
+	context   *nn.NeuralContext
+	epoch     int
+	err       error
+}
+
+func NewDataLoader(context *nn.NeuralContext, dataset Dataset, batchSize int, shuffle bool, dropLast bool) *DataLoader {
+	if batchSize <= 0 {
+		panic("data loader batch size must be positive")
+	}
+
+	return &DataLoader{
+		Dataset:   dataset,
+		BatchSize: batchSize,
+		Shuffle:   shuffle,
+		DropLast:  dropLast,
+		Collate:   StackCollate,
+		Ordered:   true,
+		context:   context,
+	}
+}
+
+func (loader *DataLoader) Len() int {
+	if loader.Sampler != nil {
+		return loader.Sampler.Len()
+	}
+
+	if loader.DropLast {
+		return loader.Dataset.Len() / loader.BatchSize
+	}
+
+	return (loader.Dataset.Len() + loader.BatchSize - 1) / loader.BatchSize
+}
+
+func (loader *DataLoader) Epoch() int {
+	return loader.epoch
+}
+
+// Reports why the last epoch stopped early, nil when every batch was yielded
+func (loader *DataLoader) Err() error {
+	return loader.err
+}
+
+type batchPlan struct {
+	indices []int
+	seed    int64
+}
+
+func (loader *DataLoader) defaultBatches() [][]int {
+	order := make([]int, loader.Dataset.Len())
+	for i := range order {
+		order[i] = i
+	}
+
+	if loader.Shuffle {
+		loader.context.Random.Shuffle(len(order), func(i, j int) {
+			order[i], order[j] = order[j], order[i]
+		})
+	}
+
+	batches := make([][]int, 0, loader.Len())
+	for start := 0; start < len(order); start += loader.BatchSize {
+		end := min(start+loader.BatchSize, len(order))
+		if loader.DropLast && end-start < loader.BatchSize {
+			break
+		}
+
+		batches = append(batches, order[start:end])
+	}
+
+	return batches
+}
+
+func (loader *DataLoader) nextEpoch() []batchPlan {
+	var batches [][]int
+	if loader.Sampler != nil {
+		batches = loader.Sampler.Sample(loader.context.Random)
+	} else {
+		batches = loader.defaultBatches()
+	}
+
+	loader.epoch++
+	_, random := loader.Dataset.(RandomDataset)
+	plans := make([]batchPlan, len(batches))
+	for i := range batches {
+		plans[i].indices = batches[i]
+		if random {
+			plans[i].seed = loader.context.Random.Int63()
+		}
+	}
+
+	return plans
+}
+
+func (loader *DataLoader) load(plan batchPlan) (*Batch, error) {
+	samples := make([]Sample, len(plan.indices))
+	var err error
+	randomDataset, ok := loader.Dataset.(RandomDataset)
+	var random *rand.Rand
+	if ok {
+		// Each batch draws from its own generator so the result does not depend on which worker decodes it
+		random = rand.New(rand.NewSource(plan.seed))
+	}
+
+	for i, index := range plan.indices {
+		if ok {
+			samples[i], err = randomDataset.GetWithRandom(index, random)
+		} else {
+			samples[i], err = loader.Dataset.Get(index)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	batch, err := loader.Collate(samples)
+	if err != nil {
+		return nil, err
+	}
+
+	batch.Indices = plan.indices
+	return batch, nil
+}
+
+// Yields one epoch of batches. A failed load or collate ends the loop like a finished epoch does, so
+// callers must check Err() after the loop to tell a truncated epoch from a complete one
+func (loader *DataLoader) Batches() iter.Seq[*Batch] {
+	return loader.BatchesContext(context.Background())
+}
+
+// Same as Batches but stops once ctx is done, the cancellation is then reported by Err()
+func (loader *DataLoader) BatchesContext(ctx context.Context) iter.Seq[*Batch] {
+	if loader.Workers > 0 {
+		return loader.prefetch(ctx)
+	}
+
+	return func(yield func(*Batch) bool) {
+		loader.err = nil
+		for _, plan := range loader.nextEpoch() {
+			if err := ctx.Err(); err != nil {
+				loader.err = err
+				return
+			}
+
+			batch, err := loader.load(plan)
+			if err != nil {
+				loader.err = err
+				return
+			}
+
+			if !yield(batch) {
+				return
+			}
+		}
+	}
+}
+
+// Yields count epochs and stops after the first one that fails, check Err() after the loop
+func (loader *DataLoader) Epochs(count int) iter.Seq2[int, iter.Seq[*Batch]] {
+	return func(yield func(int, iter.Seq[*Batch]) bool) {
+		for i := 0; i < count; i++ {
+			if !yield(loader.epoch, loader.Batches()) || loader.err != nil {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package data
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/Lyx52/micrograd-in-go.git/nn"
+)
+
+type slowDataset struct {
+	size      int
+	failAt    int
+	slowFirst bool
+}
+
+func (dataset *slowDataset) Len() int {
+	return dataset.size
+}
+
+func (dataset *slowDataset) Get(index int) (Sample, error) {
+	if index == dataset.failAt {
+		return Sample{}, errors.New("broken sample")
+	}
+
+	// Uneven decode times make workers finish out of order
+	delay := time.Duration(index*7%5) * time.Millisecond
+	if dataset.slowFirst && index == 0 {
+		delay = 50 * time.Millisecond
+	}
+
+	time.Sleep(delay)
+	return Sample{X: nn.NewTensor(float64(index))}, nil
+}
+
+func collectIndices(batches func(yield func(*Batch) bool)) []int {
+	var indices []int
+	for batch := range batches {
+		for _, value := range batch.X.Backing.Backing {
+			indices = append(indices, int(value))
+		}
+	}
+
+	return indices
+}
+
+func TestDataLoaderWorkersKeepOrder(t *testing.T) {
+	dataset := &slowDataset{size: 40, failAt: -1}
+	serial := NewDataLoader(nn.NewNeuralContext(3), dataset, 3, true, false)
+	parallel := NewDataLoader(nn.NewNeuralContext(3), dataset, 3, true, false)
+	parallel.Workers = 4
+
+	expected := collectIndices(serial.Batches())
+	got := collectIndices(parallel.Batches())
+	if err := parallel.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if !slices.Equal(got, expected) {
+		t.Fatalf("ordered workers yielded %v, expected %v", got, expected)
+	}
+}
+
+func TestDataLoaderWorkersUnordered(t *testing.T) {
+	loader := NewDataLoader(nn.NewNeuralContext(1), &slowDataset{size: 40, failAt: -1}, 3, false, false)
+	loader.Workers = 4
+	loader.Ordered = false
+
+	got := collectIndices(loader.Batches())
+	if err := loader.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	slices.Sort(got)
+	for i, index := range got {
+		if i != index {
+			t.Fatalf("unordered workers yielded %v, expected every index once", got)
+		}
+	}
+}
+
+func TestDataLoaderWorkerError(t *testing.T) {
+	loader := NewDataLoader(nn.NewNeuralContext(1), &slowDataset{size: 40, failAt: 20}, 3, false, false)
+	loader.Workers = 4
+
+	got := collectIndices(loader.Batches())
+	if loader.Err() == nil {
+		t.Fatal("expected the failing sample to surface through Err")
+	}
+
+	if slices.Contains(got, 20) || len(got) >= 40 {
+		t.Fatalf("expected a truncated epoch, got %v", got)
+	}
+}
+
+func TestDataLoaderCancel(t *testing.T) {
+	// The first batch decodes last, so the batches after it are already pending when it is yielded
+	loader := NewDataLoader(nn.NewNeuralContext(1), &slowDataset{size: 40, failAt: -1, slowFirst: true}, 2, false, false)
+	loader.Workers = 4
+	loader.Prefetch = 8
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	yielded := 0
+	for range loader.BatchesContext(ctx) {
+		yielded++
+		if yielded == 1 {
+			cancel()
+		}
+	}
+
+	if !errors.Is(loader.Err(), context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", loader.Err())
+	}
+
+	if yielded != 1 {
+		t.Fatalf("expected no batches after cancellation, got %d", yielded)
+	}
+}
+
+func TestDataLoaderEarlyBreak(t *testing.T) {
+	loader := NewDataLoader(nn.NewNeuralContext(1), &slowDataset{size: 40, failAt: -1}, 2, false, false)
+	loader.Workers = 4
+
+	for range loader.Batches() {
+		break
+	}
+
+	if err := loader.Err(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+}
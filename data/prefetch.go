@@ -0,0 +1,119 @@
+package data
+
+import (
+	"context"
+	"iter"
+	"sync"
+)
+
+type prefetchJob struct {
+	position int
+	plan     batchPlan
+}
+
+type prefetchResult struct {
+	position int
+	batch    *Batch
+	err      error
+}
+
+func (loader *DataLoader) prefetchCapacity() int {
+	if loader.Prefetch > 0 {
+		return loader.Prefetch
+	}
+
+	return 2 * loader.Workers
+}
+
+func (loader *DataLoader) worker(ctx context.Context, jobs <-chan prefetchJob, results chan<- prefetchResult) {
+	for job := range jobs {
+		batch, err := loader.load(job.plan)
+		select {
+		case results <- prefetchResult{position: job.position, batch: batch, err: err}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (loader *DataLoader) prefetch(parent context.Context) iter.Seq[*Batch] {
+	return func(yield func(*Batch) bool) {
+		loader.err = nil
+		batches := loader.nextEpoch()
+		ctx, cancel := context.WithCancel(parent)
+		capacity := loader.prefetchCapacity()
+
+		// Every dispatched batch holds a token until it has been yielded, so at most
+		// capacity batches are decoded ahead even while waiting on an out of order one
+		tokens := make(chan struct{}, capacity)
+		jobs := make(chan prefetchJob)
+		results := make(chan prefetchResult, capacity)
+
+		var group sync.WaitGroup
+		group.Add(1)
+		go func() {
+			defer group.Done()
+			defer close(jobs)
+			for position, plan := range batches {
+				select {
+				case tokens <- struct{}{}:
+				case <-ctx.Done():
+					return
+				}
+
+				select {
+				case jobs <- prefetchJob{position: position, plan: plan}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+
+		for i := 0; i < loader.Workers; i++ {
+			group.Add(1)
+			go func() {
+				defer group.Done()
+				loader.worker(ctx, jobs, results)
+			}()
+		}
+
+		defer group.Wait()
+		defer cancel()
+
+		pending := make(map[int]*Batch)
+		next := 0
+		for next < len(batches) {
+			var result prefetchResult
+			select {
+			case result = <-results:
+			case <-ctx.Done():
+				loader.err = ctx.Err()
+				return
+			}
+
+			if result.err != nil {
+				loader.err = result.err
+				return
+			}
+
+			if !loader.Ordered {
+				result.position = next
+			}
+
+			pending[result.position] = result.batch
+			for batch, ok := pending[next]; ok; batch, ok = pending[next] {
+				delete(pending, next)
+				next++
+				<-tokens
+				if err := ctx.Err(); err != nil {
+					loader.err = err
+					return
+				}
+
+				if !yield(batch) {
+					return
+				}
+			}
+		}
+	}
+}
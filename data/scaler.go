@@ -0,0 +1,409 @@
+package data
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"slices"
+
+	"github.com/Lyx52/micrograd-in-go.git/nn"
+)
+
+type ScaleMode int
+
+const (
+	PerFeature ScaleMode = iota
+	PerChannel
+	PerTensor
+)
+
+type Scaler interface {
+	Fit(tensors []*nn.Tensor) error
+	Transform(tensor *nn.Tensor) (*nn.Tensor, error)
+	InverseTransform(tensor *nn.Tensor) (*nn.Tensor, error)
+	Statistics() *ScalerStatistics
+}
+
+type ScalerStatistics struct {
+	Mode   ScaleMode `json:"mode"`
+	Shape  []int     `json:"shape"`
+	Center []float64 `json:"center"`
+	Scale  []float64 `json:"scale"`
+}
+
+func (stats *ScalerStatistics) Statistics() *ScalerStatistics {
+	return stats
+}
+
+func (stats *ScalerStatistics) IsFitted() bool {
+	return stats.Shape != nil
+}
+
+func (stats *ScalerStatistics) sampleSize() int {
+	return nn.GetTotalElements(stats.Shape)
+}
+
+func (stats *ScalerStatistics) groupCount() int {
+	switch stats.Mode {
+	case PerChannel:
+		return stats.Shape[0]
+	case PerTensor:
+		return 1
+	}
+
+	return stats.sampleSize()
+}
+
+func (stats *ScalerStatistics) group(index int) int {
+	index %= stats.sampleSize()
+	switch stats.Mode {
+	case PerChannel:
+		return index / (stats.sampleSize() / stats.Shape[0])
+	case PerTensor:
+		return 0
+	}
+
+	return index
+}
+
+func (stats *ScalerStatistics) collect(tensors []*nn.Tensor) ([][]float64, error) {
+	if len(tensors) == 0 {
+		return nil, errors.New("cannot fit scaler on an empty list of tensors")
+	}
+
+	stats.Shape = slices.Clone(tensors[0].Shape())
+	groups := make([][]float64, stats.groupCount())
+	for _, tensor := range tensors {
+		if !slices.Equal(tensor.Shape(), stats.Shape) {
+			stats.Shape = nil
+			return nil, fmt.Errorf("cannot fit scaler on tensor of shape %v, expected %v", tensor.Shape(), tensors[0].Shape())
+		}
+
+		for i, value := range tensor.Backing.Backing {
+			group := stats.group(i)
+			groups[group] = append(groups[group], value)
+		}
+	}
+
+	stats.Center = make([]float64, len(groups))
+	stats.Scale = make([]float64, len(groups))
+	return groups, nil
+}
+
+func (stats *ScalerStatistics) validate(tensor *nn.Tensor) error {
+	if !stats.IsFitted() {
+		return errors.New("scaler has not been fitted")
+	}
+
+	shape := tensor.Shape()
+	if len(tensor.Backing.Backing)%stats.sampleSize() != 0 || (len(shape) >= len(stats.Shape) && !slices.Equal(shape[len(shape)-len(stats.Shape):], stats.Shape)) {
+		return fmt.Errorf("scaler fitted on shape %v cannot transform tensor of shape %v", stats.Shape, shape)
+	}
+
+	return nil
+}
+
+func (stats *ScalerStatistics) apply(tensor *nn.Tensor, callback func(value float64, center float64, scale float64) float64) (*nn.Tensor, error) {
+	if err := stats.validate(tensor); err != nil {
+		return nil, err
+	}
+
+	result := make([]float64, len(tensor.Backing.Backing))
+	for i, value := range tensor.Backing.Backing {
+		group := stats.group(i)
+		result[i] = callback(value, stats.Center[group], stats.Scale[group])
+	}
+
+	return nn.NewTensorFromArray(result).Reshape(slices.Clone(tensor.Shape())...), nil
+}
+
+func (stats *ScalerStatistics) Transform(tensor *nn.Tensor) (*nn.Tensor, error) {
+	return stats.apply(tensor, func(value float64, center float64, scale float64) float64 {
+		return (value - center) / scale
+	})
+}
+
+func (stats *ScalerStatistics) InverseTransform(tensor *nn.Tensor) (*nn.Tensor, error) {
+	return stats.apply(tensor, func(value float64, center float64, scale float64) float64 {
+		return value*scale + center
+	})
+}
+
+func nonZeroScale(scale float64) float64 {
+	if scale < 1e-12 {
+		return 1
+	}
+
+	return scale
+}
+
+type StandardScaler struct {
+	ScalerStatistics
+}
+
+func NewStandardScaler(mode ScaleMode) *StandardScaler {
+	return &StandardScaler{ScalerStatistics{Mode: mode}}
+}
+
+func (scaler *StandardScaler) Fit(tensors []*nn.Tensor) error {
+	groups, err := scaler.collect(tensors)
+	if err != nil {
+		return err
+	}
+
+	for i, values := range groups {
+		mean := 0.0
+		for _, value := range values {
+			mean += value
+		}
+		mean /= float64(len(values))
+
+		variance := 0.0
+		for _, value := range values {
+			variance += (value - mean) * (value - mean)
+		}
+		variance /= float64(len(values))
+
+		scaler.Center[i] = mean
+		scaler.Scale[i] = nonZeroScale(math.Sqrt(variance))
+	}
+
+	return nil
+}
+
+type MinMaxScaler struct {
+	ScalerStatistics
+	RangeMin float64 `json:"range_min"`
+	RangeMax float64 `json:"range_max"`
+}
+
+func NewMinMaxScaler(mode ScaleMode, rangeMin float64, rangeMax float64) (*MinMaxScaler, error) {
+	if rangeMax <= rangeMin {
+		return nil, fmt.Errorf("min-max scaler range is empty [%v, %v]", rangeMin, rangeMax)
+	}
+
+	return &MinMaxScaler{
+		ScalerStatistics: ScalerStatistics{Mode: mode},
+		RangeMin:         rangeMin,
+		RangeMax:         rangeMax,
+	}, nil
+}
+
+func (scaler *MinMaxScaler) Fit(tensors []*nn.Tensor) error {
+	groups, err := scaler.collect(tensors)
+	if err != nil {
+		return err
+	}
+
+	for i, values := range groups {
+		scale := nonZeroScale(slices.Max(values)-slices.Min(values)) / (scaler.RangeMax - scaler.RangeMin)
+		scaler.Center[i] = slices.Min(values) - scaler.RangeMin*scale
+		scaler.Scale[i] = scale
+	}
+
+	return nil
+}
+
+type RobustScaler struct {
+	ScalerStatistics
+	QuantileLow  float64 `json:"quantile_low"`
+	QuantileHigh float64 `json:"quantile_high"`
+}
+
+func NewRobustScaler(mode ScaleMode, quantileLow float64, quantileHigh float64) (*RobustScaler, error) {
+	if quantileLow < 0 || quantileHigh > 1 || quantileHigh <= quantileLow {
+		return nil, fmt.Errorf("robust scaler quantile range is invalid [%v, %v]", quantileLow, quantileHigh)
+	}
+
+	return &RobustScaler{
+		ScalerStatistics: ScalerStatistics{Mode: mode},
+		QuantileLow:      quantileLow,
+		QuantileHigh:     quantileHigh,
+	}, nil
+}
+
+func quantile(sorted []float64, q float64) float64 {
+	position := q * float64(len(sorted)-1)
+	lower := int(math.Floor(position))
+	upper := min(lower+1, len(sorted)-1)
+	fraction := position - float64(lower)
+
+	return sorted[lower]*(1-fraction) + sorted[upper]*fraction
+}
+
+func (scaler *RobustScaler) Fit(tensors []*nn.Tensor) error {
+	groups, err := scaler.collect(tensors)
+	if err != nil {
+		return err
+	}
+
+	for i, values := range groups {
+		slices.Sort(values)
+		scaler.Center[i] = quantile(values, 0.5)
+		scaler.Scale[i] = nonZeroScale(quantile(values, scaler.QuantileHigh) - quantile(values, scaler.QuantileLow))
+	}
+
+	return nil
+}
+
+type scalerFile struct {
+	Kind   string          `json:"kind"`
+	Scaler json.RawMessage `json:"scaler"`
+}
+
+// Encodes the kind and exact fitted statistics of a scaler, UnmarshalScaler rebuilds it
+func MarshalScaler(scaler Scaler) ([]byte, error) {
+	var kind string
+	switch scaler.(type) {
+	case *StandardScaler:
+		kind = "standard"
+	case *MinMaxScaler:
+		kind = "minmax"
+	case *RobustScaler:
+		kind = "robust"
+	default:
+		return nil, fmt.Errorf("cannot save scaler of type %T", scaler)
+	}
+
+	encoded, err := json.Marshal(scaler)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.MarshalIndent(scalerFile{Kind: kind, Scaler: encoded}, "", "  ")
+}
+
+func UnmarshalScaler(encoded []byte) (Scaler, error) {
+	content := scalerFile{}
+	if err := json.Unmarshal(encoded, &content); err != nil {
+		return nil, err
+	}
+
+	var scaler Scaler
+	switch content.Kind {
+	case "standard":
+		scaler = &StandardScaler{}
+	case "minmax":
+		scaler = &MinMaxScaler{}
+	case "robust":
+		scaler = &RobustScaler{}
+	default:
+		return nil, fmt.Errorf("unknown scaler kind %q", content.Kind)
+	}
+
+	if err := json.Unmarshal(content.Scaler, scaler); err != nil {
+		return nil, err
+	}
+
+	stats := scaler.Statistics()
+	if stats.IsFitted() && (stats.sampleSize() == 0 || len(stats.Center) != stats.groupCount() || len(stats.Scale) != stats.groupCount()) {
+		return nil, fmt.Errorf("scaler statistics of shape %v do not match %d centers and %d scales", stats.Shape, len(stats.Center), len(stats.Scale))
+	}
+
+	return scaler, nil
+}
+
+func SaveScaler(scaler Scaler, filePath string) error {
+	encoded, err := MarshalScaler(scaler)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filePath, encoded, 0644)
+}
+
+func LoadScaler(filePath string) (Scaler, error) {
+	encoded, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return UnmarshalScaler(encoded)
+}
+
+type ScalerLayer struct {
+	Scaler  Scaler
+	context *nn.NeuralContext
+}
+
+func NewScalerLayer(context *nn.NeuralContext, scaler Scaler) (*ScalerLayer, error) {
+	if !scaler.Statistics().IsFitted() {
+		return nil, errors.New("scaler layer requires a fitted scaler")
+	}
+
+	return &ScalerLayer{
+		Scaler:  scaler,
+		context: context,
+	}, nil
+}
+
+func (layer *ScalerLayer) Zerograd() {
+}
+
+func (layer *ScalerLayer) UpdateParameters(updateCallback nn.UpdateTensorFunction) {
+}
+
+func (layer *ScalerLayer) SetTraining(training bool) {
+}
+
+func (layer *ScalerLayer) Execute(tensor *nn.Tensor) (*nn.Tensor, error) {
+	stats := layer.Scaler.Statistics()
+	if err := stats.validate(tensor); err != nil {
+		return nil, err
+	}
+
+	// Scaling is expressed with graph operations so gradients still reach earlier layers
+	factors := make([]float64, len(tensor.Backing.Backing))
+	offsets := make([]float64, len(tensor.Backing.Backing))
+	for i := range factors {
+		group := stats.group(i)
+		factors[i] = 1 / stats.Scale[group]
+		offsets[i] = -stats.Center[group] / stats.Scale[group]
+	}
+
+	shape := slices.Clone(tensor.Shape())
+	result, err := nn.TensorMul(tensor, nn.NewTensorFromArray(factors))
+	if err != nil {
+		return nil, err
+	}
+
+	result, err = nn.TensorAdd(result, nn.NewTensorFromArray(offsets))
+	if err != nil {
+		return nil, err
+	}
+
+	return result.Reshape(shape...), nil
+}
+
+func (layer *ScalerLayer) MarshalJSON() ([]byte, error) {
+	return MarshalScaler(layer.Scaler)
+}
+
+func (layer *ScalerLayer) UnmarshalJSON(encoded []byte) error {
+	scaler, err := UnmarshalScaler(encoded)
+	if err != nil {
+		return err
+	}
+
+	if !scaler.Statistics().IsFitted() {
+		return errors.New("scaler layer requires a fitted scaler")
+	}
+
+	layer.Scaler = scaler
+	return nil
+}
+
+func (layer *ScalerLayer) Save(filePath string) error {
+	return SaveScaler(layer.Scaler, filePath)
+}
+
+func LoadScalerLayer(context *nn.NeuralContext, filePath string) (*ScalerLayer, error) {
+	scaler, err := LoadScaler(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewScalerLayer(context, scaler)
+}
@@ -0,0 +1,116 @@
+package data
+
+import (
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/Lyx52/micrograd-in-go.git/nn"
+)
+
+func scalerSamples() []*nn.Tensor {
+	return []*nn.Tensor{
+		nn.NewTensor(1, 2, 3, 4).Reshape(2, 2),
+		nn.NewTensor(5, -2, 3, 0.3).Reshape(2, 2),
+		nn.NewTensor(-1.5, 7, 0.25, 2).Reshape(2, 2),
+	}
+}
+
+func TestScalerRoundTrip(t *testing.T) {
+	minMax, err := NewMinMaxScaler(PerChannel, -1, 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	robust, err := NewRobustScaler(PerTensor, 0.25, 0.75)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	samples := scalerSamples()
+	for _, scaler := range []Scaler{NewStandardScaler(PerFeature), minMax, robust} {
+		if err = scaler.Fit(samples); err != nil {
+			t.Fatal(err)
+		}
+
+		filePath := filepath.Join(t.TempDir(), "scaler.json")
+		if err = SaveScaler(scaler, filePath); err != nil {
+			t.Fatal(err)
+		}
+
+		restored, err := LoadScaler(filePath)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for _, sample := range samples {
+			expected, err := scaler.Transform(sample)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			got, err := restored.Transform(sample)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if !slices.Equal(got.Backing.Backing, expected.Backing.Backing) {
+				t.Errorf("%T restored output %v, expected %v", scaler, got.Backing.Backing, expected.Backing.Backing)
+			}
+		}
+	}
+}
+
+func TestScalerLayerRoundTrip(t *testing.T) {
+	context := nn.NewNeuralContext(0)
+	scaler := NewStandardScaler(PerChannel)
+	samples := scalerSamples()
+	if err := scaler.Fit(samples); err != nil {
+		t.Fatal(err)
+	}
+
+	layer, err := NewScalerLayer(context, scaler)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	filePath := filepath.Join(t.TempDir(), "layer.json")
+	if err = layer.Save(filePath); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := LoadScalerLayer(context, filePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, sample := range samples {
+		expected, err := layer.Execute(sample)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		got, err := restored.Execute(sample)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		if !slices.Equal(got.Backing.Backing, expected.Backing.Backing) {
+			t.Errorf("restored layer output %v, expected %v", got.Backing.Backing, expected.Backing.Backing)
+		}
+	}
+}
+
+func TestScalerConstructorsRejectInvalidArguments(t *testing.T) {
+	if _, err := NewMinMaxScaler(PerFeature, 1, 1); err == nil {
+		t.Error("min-max scaler accepted an empty range")
+	}
+
+	if _, err := NewRobustScaler(PerFeature, 0.8, 0.2); err == nil {
+		t.Error("robust scaler accepted a reversed quantile range")
+	}
+
+	if _, err := NewScalerLayer(nn.NewNeuralContext(0), NewStandardScaler(PerFeature)); err == nil {
+		t.Error("scaler layer accepted an unfitted scaler")
+	}
+}
@@ -0,0 +1,232 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"slices"
+
+	"github.com/Lyx52/micrograd-in-go.git/nn"
+)
+
+type SequenceDataset struct {
+	sequences [][]int
+	targets   [][]int
+}
+
+func NewSequenceDataset(sequences [][]int, targets [][]int) *SequenceDataset {
+	if targets != nil && len(sequences) != len(targets) {
+		panic(fmt.Sprintf("sequence dataset inputs and targets differ in length %v != %v", len(sequences), len(targets)))
+	}
+
+	return &SequenceDataset{sequences: sequences, targets: targets}
+}
+
+func (dataset *SequenceDataset) Len() int {
+	return len(dataset.sequences)
+}
+
+func (dataset *SequenceDataset) Lengths() []int {
+	lengths := make([]int, len(dataset.sequences))
+	for i := range dataset.sequences {
+		lengths[i] = len(dataset.sequences[i])
+	}
+
+	return lengths
+}
+
+func (dataset *SequenceDataset) Get(index int) (Sample, error) {
+	if index < 0 || index >= len(dataset.sequences) {
+		return Sample{}, fmt.Errorf("sequence index %d out of range [0, %d)", index, len(dataset.sequences))
+	}
+
+	sample := Sample{X: IdTensor(dataset.sequences[index])}
+	if dataset.targets != nil {
+		sample.Y = IdTensor(dataset.targets[index])
+	}
+
+	return sample, nil
+}
+
+type SlidingWindowDataset struct {
+	stream []int
+	window int
+	stride int
+}
+
+func NewSlidingWindowDataset(stream []int, window int, stride int) *SlidingWindowDataset {
+	if window <= 0 || stride <= 0 {
+		panic("sliding window size and stride must be positive")
+	}
+
+	return &SlidingWindowDataset{stream: stream, window: window, stride: stride}
+}
+
+func (dataset *SlidingWindowDataset) Len() int {
+	if len(dataset.stream) <= dataset.window {
+		return 0
+	}
+
+	return (len(dataset.stream)-dataset.window-1)/dataset.stride + 1
+}
+
+func (dataset *SlidingWindowDataset) Get(index int) (Sample, error) {
+	if index < 0 || index >= dataset.Len() {
+		return Sample{}, fmt.Errorf("window index %d out of range [0, %d)", index, dataset.Len())
+	}
+
+	start := index * dataset.stride
+	return Sample{
+		X: IdTensor(dataset.stream[start : start+dataset.window]),
+		Y: IdTensor(dataset.stream[start+1 : start+dataset.window+1]),
+	}, nil
+}
+
+func padStack(tensors []*nn.Tensor, length int, value float64) (*nn.Tensor, error) {
+	inner := tensors[0].Shape()[1:]
+	innerSize := max(1, nn.GetTotalElements(inner))
+	values := make([]float64, 0, len(tensors)*length*innerSize)
+	for _, tensor := range tensors {
+		shape := tensor.Shape()
+		if !slices.Equal(shape[1:], inner) {
+			return nil, fmt.Errorf("cannot pad sequence of shape %v with sequence of shape %v", shape, tensors[0].Shape())
+		}
+
+		values = append(values, tensor.Backing.Backing...)
+		for i := shape[0] * innerSize; i < length*innerSize; i++ {
+			values = append(values, value)
+		}
+	}
+
+	return nn.NewTensorFromArray(values).Reshape(append([]int{len(tensors), length}, inner...)...), nil
+}
+
+func PadCollate(padValue float64, targetPadValue float64, padTargets bool) CollateFunction {
+	return func(samples []Sample) (*Batch, error) {
+		if len(samples) == 0 {
+			return nil, errors.New("cannot collate an empty batch")
+		}
+
+		xs := make([]*nn.Tensor, len(samples))
+		ys := make([]*nn.Tensor, 0, len(samples))
+		lengths := make([]int, len(samples))
+		longest := 0
+		for i := range samples {
+			xs[i] = samples[i].X
+			lengths[i] = samples[i].X.Shape()[0]
+			longest = max(longest, lengths[i])
+			if samples[i].Y != nil {
+				ys = append(ys, samples[i].Y)
+			}
+		}
+
+		if len(ys) != 0 && len(ys) != len(samples) {
+			return nil, errors.New("cannot collate a batch where only some samples have targets")
+		}
+
+		x, err := padStack(xs, longest, padValue)
+		if err != nil {
+			return nil, err
+		}
+
+		mask := nn.NewTensorEmpty(len(samples)*longest).Reshape(len(samples), longest)
+		for i, length := range lengths {
+			for t := 0; t < length; t++ {
+				mask.Backing.Backing[i*longest+t] = 1.0
+			}
+		}
+
+		batch := &Batch{X: x, Mask: mask, Lengths: lengths}
+		if len(ys) == 0 {
+			return batch, nil
+		}
+
+		if padTargets {
+			targetLongest := 0
+			for i := range ys {
+				targetLongest = max(targetLongest, ys[i].Shape()[0])
+			}
+
+			batch.Y, err = padStack(ys, targetLongest, targetPadValue)
+		} else {
+			batch.Y, err = nn.Stack(ys)
+		}
+
+		if err != nil {
+			return nil, err
+		}
+
+		return batch, nil
+	}
+}
+
+type BucketSampler struct {
+	lengths    []int
+	batchSize  int
+	bucketSize int
+	dropLast   bool
+}
+
+func NewBucketSampler(lengths []int, batchSize int, bucketSize int, dropLast bool) *BucketSampler {
+	if batchSize <= 0 || bucketSize <= 0 {
+		panic("bucket sampler batch and bucket size must be positive")
+	}
+
+	return &BucketSampler{
+		lengths:    lengths,
+		batchSize:  batchSize,
+		bucketSize: bucketSize,
+		dropLast:   dropLast,
+	}
+}
+
+func (sampler *BucketSampler) Len() int {
+	count := 0
+	pool := sampler.batchSize * sampler.bucketSize
+	for start := 0; start < len(sampler.lengths); start += pool {
+		size := min(pool, len(sampler.lengths)-start)
+		if sampler.dropLast {
+			count += size / sampler.batchSize
+		} else {
+			count += (size + sampler.batchSize - 1) / sampler.batchSize
+		}
+	}
+
+	return count
+}
+
+func (sampler *BucketSampler) Sample(random *rand.Rand) [][]int {
+	order := make([]int, len(sampler.lengths))
+	for i := range order {
+		order[i] = i
+	}
+
+	random.Shuffle(len(order), func(i, j int) {
+		order[i], order[j] = order[j], order[i]
+	})
+
+	// Sorting inside a pool of several batches groups similar lengths while keeping epochs random
+	batches := make([][]int, 0, sampler.Len())
+	pool := sampler.batchSize * sampler.bucketSize
+	for start := 0; start < len(order); start += pool {
+		bucket := order[start:min(start+pool, len(order))]
+		slices.SortStableFunc(bucket, func(a int, b int) int {
+			return sampler.lengths[a] - sampler.lengths[b]
+		})
+
+		for offset := 0; offset < len(bucket); offset += sampler.batchSize {
+			end := min(offset+sampler.batchSize, len(bucket))
+			if sampler.dropLast && end-offset < sampler.batchSize {
+				break
+			}
+
+			batches = append(batches, bucket[offset:end])
+		}
+	}
+
+	random.Shuffle(len(batches), func(i, j int) {
+		batches[i], batches[j] = batches[j], batches[i]
+	})
+
+	return batches
+}
@@ -0,0 +1,332 @@
+package data
+
+import (
+	"errors"
+	"fmt"
+	"iter"
+	"math"
+	"math/rand"
+	"slices"
+
+	"github.com/Lyx52/micrograd-in-go.git/nn"
+)
+
+type Subset struct {
+	Indices []int
+	dataset Dataset
+}
+
+func NewSubset(dataset Dataset, indices []int) *Subset {
+	return &Subset{
+		Indices: indices,
+		dataset: dataset,
+	}
+}
+
+func (subset *Subset) Len() int {
+	return len(subset.Indices)
+}
+
+func (subset *Subset) Get(index int) (Sample, error) {
+	if index < 0 || index >= len(subset.Indices) {
+		return Sample{}, fmt.Errorf("subset index %d out of range [0, %d)", index, len(subset.Indices))
+	}
+
+	return subset.dataset.Get(subset.Indices[index])
+}
+
+func (subset *Subset) GetWithRandom(index int, random *rand.Rand) (Sample, error) {
+	if index < 0 || index >= len(subset.Indices) {
+		return Sample{}, fmt.Errorf("subset index %d out of range [0, %d)", index, len(subset.Indices))
+	}
+
+	return getWithRandom(subset.dataset, subset.Indices[index], random)
+}
+
+type Fold struct {
+	Train      *Subset
+	Validation *Subset
+}
+
+func shuffledIndices(context *nn.NeuralContext, indices []int) []int {
+	result := slices.Clone(indices)
+	context.Random.Shuffle(len(result), func(i, j int) {
+		result[i], result[j] = result[j], result[i]
+	})
+
+	return result
+}
+
+func rangeIndices(length int) []int {
+	result := make([]int, length)
+	for i := range result {
+		result[i] = i
+	}
+
+	return result
+}
+
+func splitBoundaries(length int, fractions []float64) ([]int, error) {
+	if len(fractions) == 0 {
+		return nil, errors.New("expected at least one split fraction")
+	}
+
+	total := 0.0
+	for _, fraction := range fractions {
+		if fraction < 0 {
+			return nil, fmt.Errorf("split fraction %v is negative", fraction)
+		}
+
+		total += fraction
+	}
+
+	if math.Abs(total-1) > 1e-6 {
+		return nil, fmt.Errorf("split fractions must sum to 1, got %v", total)
+	}
+
+	boundaries := make([]int, len(fractions)+1)
+	cumulative := 0.0
+	for i, fraction := range fractions {
+		cumulative += fraction
+		boundaries[i+1] = int(math.Round(cumulative * float64(length)))
+	}
+
+	boundaries[len(fractions)] = length
+	return boundaries, nil
+}
+
+func splitIndices(indices []int, fractions []float64) ([][]int, error) {
+	boundaries, err := splitBoundaries(len(indices), fractions)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([][]int, len(fractions))
+	for i := range result {
+		result[i] = slices.Clone(indices[boundaries[i]:boundaries[i+1]])
+	}
+
+	return result, nil
+}
+
+func toSubsets(dataset Dataset, parts [][]int) []*Subset {
+	result := make([]*Subset, len(parts))
+	for i := range parts {
+		result[i] = NewSubset(dataset, parts[i])
+	}
+
+	return result
+}
+
+func groupIndices(labels []int) (map[int][]int, []int) {
+	groups := make(map[int][]int)
+	keys := make([]int, 0)
+	for i, label := range labels {
+		if _, ok := groups[label]; !ok {
+			keys = append(keys, label)
+		}
+
+		groups[label] = append(groups[label], i)
+	}
+
+	slices.Sort(keys)
+	return groups, keys
+}
+
+func validateLabels(dataset Dataset, labels []int) error {
+	if len(labels) != dataset.Len() {
+		return fmt.Errorf("expected %d labels, got %d", dataset.Len(), len(labels))
+	}
+
+	return nil
+}
+
+func RandomSplit(context *nn.NeuralContext, dataset Dataset, fractions ...float64) ([]*Subset, error) {
+	parts, err := splitIndices(shuffledIndices(context, rangeIndices(dataset.Len())), fractions)
+	if err != nil {
+		return nil, err
+	}
+
+	return toSubsets(dataset, parts), nil
+}
+
+func StratifiedSplit(context *nn.NeuralContext, dataset Dataset, labels []int, fractions ...float64) ([]*Subset, error) {
+	if err := validateLabels(dataset, labels); err != nil {
+		return nil, err
+	}
+
+	parts := make([][]int, len(fractions))
+	classes, keys := groupIndices(labels)
+	for _, key := range keys {
+		classParts, err := splitIndices(shuffledIndices(context, classes[key]), fractions)
+		if err != nil {
+			return nil, err
+		}
+
+		for i := range parts {
+			parts[i] = append(parts[i], classParts[i]...)
+		}
+	}
+
+	for i := range parts {
+		parts[i] = shuffledIndices(context, parts[i])
+	}
+
+	return toSubsets(dataset, parts), nil
+}
+
+func GroupSplit(context *nn.NeuralContext, dataset Dataset, groups []int, fractions ...float64) ([]*Subset, error) {
+	if err := validateLabels(dataset, groups); err != nil {
+		return nil, err
+	}
+
+	members, keys := groupIndices(groups)
+	groupParts, err := splitIndices(shuffledIndices(context, keys), fractions)
+	if err != nil {
+		return nil, err
+	}
+
+	parts := make([][]int, len(fractions))
+	for i := range groupParts {
+		for _, key := range groupParts[i] {
+			parts[i] = append(parts[i], members[key]...)
+		}
+	}
+
+	return toSubsets(dataset, parts), nil
+}
+
+func foldsFromParts(dataset Dataset, parts [][]int) iter.Seq2[int, Fold] {
+	return func(yield func(int, Fold) bool) {
+		for i := range parts {
+			train := make([]int, 0, dataset.Len()-len(parts[i]))
+			for j := range parts {
+				if j != i {
+					train = append(train, parts[j]...)
+				}
+			}
+
+			if !yield(i, Fold{Train: NewSubset(dataset, train), Validation: NewSubset(dataset, parts[i])}) {
+				return
+			}
+		}
+	}
+}
+
+func validateFolds(dataset Dataset, folds int) error {
+	if folds < 2 || folds > dataset.Len() {
+		return fmt.Errorf("fold count %d must be in range [2, %d]", folds, dataset.Len())
+	}
+
+	return nil
+}
+
+func KFold(context *nn.NeuralContext, dataset Dataset, folds int, shuffle bool) (iter.Seq2[int, Fold], error) {
+	if err := validateFolds(dataset, folds); err != nil {
+		return nil, err
+	}
+
+	indices := rangeIndices(dataset.Len())
+	if shuffle {
+		indices = shuffledIndices(context, indices)
+	}
+
+	parts := make([][]int, folds)
+	for i := range parts {
+		parts[i] = indices[i*len(indices)/folds : (i+1)*len(indices)/folds]
+	}
+
+	return foldsFromParts(dataset, parts), nil
+}
+
+func StratifiedKFold(context *nn.NeuralContext, dataset Dataset, labels []int, folds int) (iter.Seq2[int, Fold], error) {
+	if err := validateFolds(dataset, folds); err != nil {
+		return nil, err
+	}
+
+	if err := validateLabels(dataset, labels); err != nil {
+		return nil, err
+	}
+
+	parts := make([][]int, folds)
+	classes, keys := groupIndices(labels)
+	next := 0
+	for _, key := range keys {
+		for _, index := range shuffledIndices(context, classes[key]) {
+			parts[next] = append(parts[next], index)
+			next = (next + 1) % folds
+		}
+	}
+
+	return foldsFromParts(dataset, parts), nil
+}
+
+func TimeSeriesSplit(dataset Dataset, folds int) (iter.Seq2[int, Fold], error) {
+	if folds < 1 || folds+1 > dataset.Len() {
+		return nil, fmt.Errorf("time series fold count %d must be in range [1, %d]", folds, dataset.Len()-1)
+	}
+
+	size := dataset.Len() / (folds + 1)
+	start := dataset.Len() - folds*size
+
+	return func(yield func(int, Fold) bool) {
+		for i := 0; i < folds; i++ {
+			end := start + i*size
+			fold := Fold{
+				Train:      NewSubset(dataset, rangeIndices(end)),
+				Validation: NewSubset(dataset, rangeIndices(end + size)[end:]),
+			}
+
+			if !yield(i, fold) {
+				return
+			}
+		}
+	}, nil
+}
+
+type Metrics map[string]float64
+
+type CrossValidationResult struct {
+	Folds []Metrics
+	Mean  Metrics
+	Std   Metrics
+}
+
+type FoldTrainer func(module nn.ICallable, train Dataset, validation Dataset) (Metrics, error)
+
+func CrossValidate(folds iter.Seq2[int, Fold], build func() nn.ICallable, trainer FoldTrainer) (*CrossValidationResult, error) {
+	result := &CrossValidationResult{
+		Folds: make([]Metrics, 0),
+		Mean:  make(Metrics),
+		Std:   make(Metrics),
+	}
+
+	for i, fold := range folds {
+		metrics, err := trainer(build(), fold.Train, fold.Validation)
+		if err != nil {
+			return nil, fmt.Errorf("fold %d: %w", i, err)
+		}
+
+		result.Folds = append(result.Folds, metrics)
+	}
+
+	if len(result.Folds) == 0 {
+		return nil, errors.New("cross validation received no folds")
+	}
+
+	count := float64(len(result.Folds))
+	for name := range result.Folds[0] {
+		for _, metrics := range result.Folds {
+			result.Mean[name] += metrics[name] / count
+		}
+
+		for _, metrics := range result.Folds {
+			difference := metrics[name] - result.Mean[name]
+			result.Std[name] += difference * difference / count
+		}
+
+		result.Std[name] = math.Sqrt(result.Std[name])
+	}
+
+	return result, nil
+}
@@ -0,0 +1,174 @@
+package data
+
+import (
+	"math"
+	"math/rand"
+	"slices"
+
+	"github.com/Lyx52/micrograd-in-go.git/nn"
+)
+
+func newSyntheticRandom(seed int64) *rand.Rand {
+	return nn.NewNeuralContext(seed).Random
+}
+
+func pointTensors(points [][]float64) []*nn.Tensor {
+	result := make([]*nn.Tensor, len(points))
+	for i := range points {
+		result[i] = nn.NewTensorFromArray(points[i])
+	}
+
+	return result
+}
+
+func classTensors(labels []int, classes int) []*nn.Tensor {
+	result := make([]*nn.Tensor, len(labels))
+	for i, label := range labels {
+		result[i] = nn.NewTensorEmpty(classes)
+		result[i].Backing.Backing[label] = 1.0
+	}
+
+	return result
+}
+
+func MakeXor(samples int, noise float64, seed int64) ([]*nn.Tensor, []*nn.Tensor) {
+	random := newSyntheticRandom(seed)
+	xs, ys := make([]*nn.Tensor, samples), make([]*nn.Tensor, samples)
+	for i := 0; i < samples; i++ {
+		a, b := float64(random.Intn(2)), float64(random.Intn(2))
+		xs[i] = nn.NewTensor(a+random.NormFloat64()*noise, b+random.NormFloat64()*noise)
+		ys[i] = nn.NewTensor(math.Abs(a - b))
+	}
+
+	return xs, ys
+}
+
+func MakeMoons(samples int, noise float64, seed int64) ([]*nn.Tensor, []*nn.Tensor) {
+	random := newSyntheticRandom(seed)
+	points, labels := make([][]float64, samples), make([]int, samples)
+	for i := 0; i < samples; i++ {
+		labels[i] = i % 2
+		angle := random.Float64() * math.Pi
+		if labels[i] == 0 {
+			points[i] = []float64{math.Cos(angle), math.Sin(angle)}
+		} else {
+			points[i] = []float64{1 - math.Cos(angle), 0.5 - math.Sin(angle)}
+		}
+
+		points[i][0] += random.NormFloat64() * noise
+		points[i][1] += random.NormFloat64() * noise
+	}
+
+	return pointTensors(points), classTensors(labels, 2)
+}
+
+func MakeCircles(samples int, noise float64, factor float64, seed int64) ([]*nn.Tensor, []*nn.Tensor) {
+	random := newSyntheticRandom(seed)
+	points, labels := make([][]float64, samples), make([]int, samples)
+	for i := 0; i < samples; i++ {
+		labels[i] = i % 2
+		radius := 1.0
+		if labels[i] == 1 {
+			radius = factor
+		}
+
+		angle := random.Float64() * 2 * math.Pi
+		points[i] = []float64{
+			radius*math.Cos(angle) + random.NormFloat64()*noise,
+			radius*math.Sin(angle) + random.NormFloat64()*noise,
+		}
+	}
+
+	return pointTensors(points), classTensors(labels, 2)
+}
+
+// Without classes there is nothing to draw from, so the result is empty
+func MakeSpirals(samples int, classes int, noise float64, seed int64) ([]*nn.Tensor, []*nn.Tensor) {
+	if classes <= 0 {
+		return []*nn.Tensor{}, []*nn.Tensor{}
+	}
+
+	random := newSyntheticRandom(seed)
+	points, labels := make([][]float64, samples), make([]int, samples)
+	for i := 0; i < samples; i++ {
+		labels[i] = i % classes
+		radius := random.Float64()
+		angle := float64(labels[i])*2*math.Pi/float64(classes) + radius*4 + random.NormFloat64()*noise
+		points[i] = []float64{radius * math.Sin(angle), radius * math.Cos(angle)}
+	}
+
+	return pointTensors(points), classTensors(labels, classes)
+}
+
+// Without centers there is nothing to draw from, so the result is empty
+func MakeBlobs(samples int, centers [][]float64, std float64, seed int64) ([]*nn.Tensor, []*nn.Tensor) {
+	if len(centers) == 0 {
+		return []*nn.Tensor{}, []*nn.Tensor{}
+	}
+
+	random := newSyntheticRandom(seed)
+	points, labels := make([][]float64, samples), make([]int, samples)
+	for i := 0; i < samples; i++ {
+		labels[i] = i % len(centers)
+		points[i] = make([]float64, len(centers[labels[i]]))
+		for j, center := range centers[labels[i]] {
+			points[i][j] = center + random.NormFloat64()*std
+		}
+	}
+
+	return pointTensors(points), classTensors(labels, len(centers))
+}
+
+func MakeRegression(samples int, weights []float64, bias float64, noise float64, seed int64) ([]*nn.Tensor, []*nn.Tensor) {
+	random := newSyntheticRandom(seed)
+	xs, ys := make([]*nn.Tensor, samples), make([]*nn.Tensor, samples)
+	for i := 0; i < samples; i++ {
+		features := make([]float64, len(weights))
+		target := bias
+		for j := range features {
+			features[j] = random.NormFloat64()
+			target += weights[j] * features[j]
+		}
+
+		xs[i] = nn.NewTensorFromArray(features)
+		ys[i] = nn.NewTensor(target + random.NormFloat64()*noise)
+	}
+
+	return xs, ys
+}
+
+func MakePolynomial(samples int, coefficients []float64, low float64, high float64, noise float64, seed int64) ([]*nn.Tensor, []*nn.Tensor) {
+	random := newSyntheticRandom(seed)
+	xs, ys := make([]*nn.Tensor, samples), make([]*nn.Tensor, samples)
+	for i := 0; i < samples; i++ {
+		x := low + random.Float64()*(high-low)
+		target, power := 0.0, 1.0
+		for _, coefficient := range coefficients {
+			target += coefficient * power
+			power *= x
+		}
+
+		xs[i] = nn.NewTensor(x)
+		ys[i] = nn.NewTensor(target + random.NormFloat64()*noise)
+	}
+
+	return xs, ys
+}
+
+func MakeSineSequences(samples int, length int, noise float64, seed int64) ([]*nn.Tensor, []*nn.Tensor) {
+	random := newSyntheticRandom(seed)
+	xs, ys := make([]*nn.Tensor, samples), make([]*nn.Tensor, samples)
+	for i := 0; i < samples; i++ {
+		frequency := 0.1 + random.Float64()*0.4
+		phase := random.Float64() * 2 * math.Pi
+		values := make([]float64, length+1)
+		for t := range values {
+			values[t] = math.Sin(frequency*float64(t)+phase) + random.NormFloat64()*noise
+		}
+
+		xs[i] = nn.NewTensorFromArray(slices.Clone(values[:length])).Reshape(length, 1)
+		ys[i] = nn.NewTensorFromArray(slices.Clone(values[1:])).Reshape(length, 1)
+	}
+
+	return xs, ys
+}
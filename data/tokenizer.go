@@ -0,0 +1,449 @@
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+
+	"github.com/Lyx52/micrograd-in-go.git/nn"
+)
+
+const (
+	PadToken     = "<pad>"
+	UnknownToken = "<unk>"
+	BeginToken   = "<bos>"
+	EndToken     = "<eos>"
+)
+
+var specialTokens = []string{PadToken, UnknownToken, BeginToken, EndToken}
+
+type Tokenizer interface {
+	Encode(text string) []int
+	Decode(ids []int) string
+	Vocabulary() *Vocabulary
+	Save(filePath string) error
+}
+
+type Vocabulary struct {
+	tokens []string
+	ids    map[string]int
+}
+
+func NewVocabulary(tokens []string) *Vocabulary {
+	vocabulary := &Vocabulary{ids: make(map[string]int)}
+	vocabulary.AddAll(specialTokens)
+	vocabulary.AddAll(tokens)
+
+	return vocabulary
+}
+
+func (vocabulary *Vocabulary) Add(token string) int {
+	if id, ok := vocabulary.ids[token]; ok {
+		return id
+	}
+
+	vocabulary.ids[token] = len(vocabulary.tokens)
+	vocabulary.tokens = append(vocabulary.tokens, token)
+
+	return len(vocabulary.tokens) - 1
+}
+
+func (vocabulary *Vocabulary) AddAll(tokens []string) {
+	for _, token := range tokens {
+		vocabulary.Add(token)
+	}
+}
+
+func (vocabulary *Vocabulary) Len() int {
+	return len(vocabulary.tokens)
+}
+
+func (vocabulary *Vocabulary) Id(token string) int {
+	if id, ok := vocabulary.ids[token]; ok {
+		return id
+	}
+
+	return vocabulary.ids[UnknownToken]
+}
+
+func (vocabulary *Vocabulary) Token(id int) string {
+	if id < 0 || id >= len(vocabulary.tokens) {
+		return UnknownToken
+	}
+
+	return vocabulary.tokens[id]
+}
+
+func (vocabulary *Vocabulary) Tokens() []string {
+	return slices.Clone(vocabulary.tokens)
+}
+
+func (vocabulary *Vocabulary) PadId() int {
+	return vocabulary.ids[PadToken]
+}
+
+func (vocabulary *Vocabulary) UnknownId() int {
+	return vocabulary.ids[UnknownToken]
+}
+
+func (vocabulary *Vocabulary) BeginId() int {
+	return vocabulary.ids[BeginToken]
+}
+
+func (vocabulary *Vocabulary) EndId() int {
+	return vocabulary.ids[EndToken]
+}
+
+func (vocabulary *Vocabulary) isSkipped(id int) bool {
+	return id == vocabulary.PadId() || id == vocabulary.BeginId() || id == vocabulary.EndId()
+}
+
+func AddBounds(tokenizer Tokenizer, ids []int) []int {
+	vocabulary := tokenizer.Vocabulary()
+	result := make([]int, 0, len(ids)+2)
+	result = append(result, vocabulary.BeginId())
+	result = append(result, ids...)
+
+	return append(result, vocabulary.EndId())
+}
+
+func IdTensor(ids []int) *nn.Tensor {
+	values := make([]float64, len(ids))
+	for i, id := range ids {
+		values[i] = float64(id)
+	}
+
+	return nn.NewTensorFromArray(values)
+}
+
+func TensorIds(tensor *nn.Tensor) []int {
+	ids := make([]int, len(tensor.Backing.Backing))
+	for i, value := range tensor.Backing.Backing {
+		ids[i] = int(value)
+	}
+
+	return ids
+}
+
+type CharTokenizer struct {
+	vocabulary *Vocabulary
+}
+
+func NewCharTokenizer(corpus string) *CharTokenizer {
+	runes := []rune(corpus)
+	slices.Sort(runes)
+	runes = slices.Compact(runes)
+
+	tokens := make([]string, len(runes))
+	for i, character := range runes {
+		tokens[i] = string(character)
+	}
+
+	return &CharTokenizer{vocabulary: NewVocabulary(tokens)}
+}
+
+func (tokenizer *CharTokenizer) Vocabulary() *Vocabulary {
+	return tokenizer.vocabulary
+}
+
+func (tokenizer *CharTokenizer) Encode(text string) []int {
+	ids := make([]int, 0, len(text))
+	for _, character := range text {
+		ids = append(ids, tokenizer.vocabulary.Id(string(character)))
+	}
+
+	return ids
+}
+
+func (tokenizer *CharTokenizer) Decode(ids []int) string {
+	var builder strings.Builder
+	for _, id := range ids {
+		if !tokenizer.vocabulary.isSkipped(id) {
+			builder.WriteString(tokenizer.vocabulary.Token(id))
+		}
+	}
+
+	return builder.String()
+}
+
+func (tokenizer *CharTokenizer) Save(filePath string) error {
+	return saveTokenizer(filePath, tokenizerFile{Kind: "char", Tokens: tokenizer.vocabulary.tokens})
+}
+
+type WordTokenizer struct {
+	vocabulary *Vocabulary
+}
+
+func NewWordTokenizer(corpus string, maxVocabulary int, minFrequency int) *WordTokenizer {
+	counts := make(map[string]int)
+	for _, word := range strings.Fields(corpus) {
+		counts[word]++
+	}
+
+	words := make([]string, 0, len(counts))
+	for word, count := range counts {
+		if count >= minFrequency {
+			words = append(words, word)
+		}
+	}
+
+	slices.SortFunc(words, func(a string, b string) int {
+		if counts[a] != counts[b] {
+			return counts[b] - counts[a]
+		}
+
+		return strings.Compare(a, b)
+	})
+
+	if maxVocabulary > 0 && len(words) > maxVocabulary-len(specialTokens) {
+		words = words[:max(0, maxVocabulary-len(specialTokens))]
+	}
+
+	return &WordTokenizer{vocabulary: NewVocabulary(words)}
+}
+
+func (tokenizer *WordTokenizer) Vocabulary() *Vocabulary {
+	return tokenizer.vocabulary
+}
+
+func (tokenizer *WordTokenizer) Encode(text string) []int {
+	words := strings.Fields(text)
+	ids := make([]int, len(words))
+	for i, word := range words {
+		ids[i] = tokenizer.vocabulary.Id(word)
+	}
+
+	return ids
+}
+
+func (tokenizer *WordTokenizer) Decode(ids []int) string {
+	words := make([]string, 0, len(ids))
+	for _, id := range ids {
+		if !tokenizer.vocabulary.isSkipped(id) {
+			words = append(words, tokenizer.vocabulary.Token(id))
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+func (tokenizer *WordTokenizer) Save(filePath string) error {
+	return saveTokenizer(filePath, tokenizerFile{Kind: "word", Tokens: tokenizer.vocabulary.tokens})
+}
+
+type bpePair struct {
+	first  string
+	second string
+}
+
+type BPETokenizer struct {
+	vocabulary *Vocabulary
+	merges     []bpePair
+	ranks      map[bpePair]int
+}
+
+// Words keep their trailing whitespace so decoding can simply concatenate tokens
+func splitBPEWords(text string) []string {
+	words := make([]string, 0)
+	start := 0
+	previousSpace := true
+	for i, character := range text {
+		space := unicode.IsSpace(character)
+		if !space && previousSpace && i > start {
+			if strings.TrimSpace(text[start:i]) != "" {
+				words = append(words, text[start:i])
+				start = i
+			}
+		}
+
+		previousSpace = space
+	}
+
+	if start < len(text) {
+		words = append(words, text[start:])
+	}
+
+	return words
+}
+
+func splitRunes(word string) []string {
+	symbols := make([]string, 0, len(word))
+	for _, character := range word {
+		symbols = append(symbols, string(character))
+	}
+
+	return symbols
+}
+
+func mergePair(symbols []string, pair bpePair) []string {
+	result := make([]string, 0, len(symbols))
+	for i := 0; i < len(symbols); i++ {
+		if i+1 < len(symbols) && symbols[i] == pair.first && symbols[i+1] == pair.second {
+			result = append(result, pair.first+pair.second)
+			i++
+			continue
+		}
+
+		result = append(result, symbols[i])
+	}
+
+	return result
+}
+
+func TrainBPETokenizer(corpus string, vocabularySize int) *BPETokenizer {
+	counts := make(map[string]int)
+	for _, word := range splitBPEWords(corpus) {
+		counts[word]++
+	}
+
+	words := make([]string, 0, len(counts))
+	for word := range counts {
+		words = append(words, word)
+	}
+	slices.Sort(words)
+
+	symbols := make([][]string, len(words))
+	alphabet := make([]string, 0)
+	for i, word := range words {
+		symbols[i] = splitRunes(word)
+		alphabet = append(alphabet, symbols[i]...)
+	}
+
+	slices.Sort(alphabet)
+	tokenizer := &BPETokenizer{
+		vocabulary: NewVocabulary(slices.Compact(alphabet)),
+		ranks:      make(map[bpePair]int),
+	}
+
+	for tokenizer.vocabulary.Len() < vocabularySize {
+		pairCounts := make(map[bpePair]int)
+		for i, word := range words {
+			for j := 0; j+1 < len(symbols[i]); j++ {
+				pairCounts[bpePair{symbols[i][j], symbols[i][j+1]}] += counts[word]
+			}
+		}
+
+		best, bestCount := bpePair{}, 0
+		for pair, count := range pairCounts {
+			if count > bestCount || (count == bestCount && (pair.first+"\x00"+pair.second) < (best.first+"\x00"+best.second)) {
+				best, bestCount = pair, count
+			}
+		}
+
+		if bestCount == 0 {
+			break
+		}
+
+		tokenizer.addMerge(best)
+		for i := range symbols {
+			symbols[i] = mergePair(symbols[i], best)
+		}
+	}
+
+	return tokenizer
+}
+
+func (tokenizer *BPETokenizer) addMerge(pair bpePair) {
+	tokenizer.ranks[pair] = len(tokenizer.merges)
+	tokenizer.merges = append(tokenizer.merges, pair)
+	tokenizer.vocabulary.Add(pair.first + pair.second)
+}
+
+func (tokenizer *BPETokenizer) Vocabulary() *Vocabulary {
+	return tokenizer.vocabulary
+}
+
+func (tokenizer *BPETokenizer) Encode(text string) []int {
+	ids := make([]int, 0, len(text))
+	for _, word := range splitBPEWords(text) {
+		symbols := splitRunes(word)
+		for len(symbols) > 1 {
+			best, bestRank := bpePair{}, -1
+			for j := 0; j+1 < len(symbols); j++ {
+				pair := bpePair{symbols[j], symbols[j+1]}
+				if rank, ok := tokenizer.ranks[pair]; ok && (bestRank < 0 || rank < bestRank) {
+					best, bestRank = pair, rank
+				}
+			}
+
+			if bestRank < 0 {
+				break
+			}
+
+			symbols = mergePair(symbols, best)
+		}
+
+		for _, symbol := range symbols {
+			ids = append(ids, tokenizer.vocabulary.Id(symbol))
+		}
+	}
+
+	return ids
+}
+
+func (tokenizer *BPETokenizer) Decode(ids []int) string {
+	var builder strings.Builder
+	for _, id := range ids {
+		if !tokenizer.vocabulary.isSkipped(id) {
+			builder.WriteString(tokenizer.vocabulary.Token(id))
+		}
+	}
+
+	return builder.String()
+}
+
+func (tokenizer *BPETokenizer) Save(filePath string) error {
+	merges := make([][2]string, len(tokenizer.merges))
+	for i, pair := range tokenizer.merges {
+		merges[i] = [2]string{pair.first, pair.second}
+	}
+
+	return saveTokenizer(filePath, tokenizerFile{Kind: "bpe", Tokens: tokenizer.vocabulary.tokens, Merges: merges})
+}
+
+type tokenizerFile struct {
+	Kind   string      `json:"kind"`
+	Tokens []string    `json:"tokens"`
+	Merges [][2]string `json:"merges,omitempty"`
+}
+
+func saveTokenizer(filePath string, content tokenizerFile) error {
+	encoded, err := json.MarshalIndent(content, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filePath, encoded, 0644)
+}
+
+func LoadTokenizer(filePath string) (Tokenizer, error) {
+	encoded, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	content := tokenizerFile{}
+	if err = json.Unmarshal(encoded, &content); err != nil {
+		return nil, err
+	}
+
+	vocabulary := NewVocabulary(content.Tokens)
+	switch content.Kind {
+	case "char":
+		return &CharTokenizer{vocabulary: vocabulary}, nil
+	case "word":
+		return &WordTokenizer{vocabulary: vocabulary}, nil
+	case "bpe":
+		tokenizer := &BPETokenizer{vocabulary: vocabulary, ranks: make(map[bpePair]int)}
+		for _, merge := range content.Merges {
+			tokenizer.addMerge(bpePair{merge[0], merge[1]})
+		}
+
+		return tokenizer, nil
+	}
+
+	return nil, fmt.Errorf("unknown tokenizer kind %q", content.Kind)
+}
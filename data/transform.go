@@ -0,0 +1,154 @@
+package data
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"slices"
+
+	"github.com/Lyx52/micrograd-in-go.git/nn"
+)
+
+type Transform func(random *rand.Rand, tensor *nn.Tensor) (*nn.Tensor, error)
+
+func Compose(transforms ...Transform) Transform {
+	return func(random *rand.Rand, tensor *nn.Tensor) (*nn.Tensor, error) {
+		result := tensor
+		var err error
+
+		for i := range transforms {
+			result, err = transforms[i](random, result)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return result, nil
+	}
+}
+
+type TransformDataset struct {
+	dataset   Dataset
+	transform Transform
+	context   *nn.NeuralContext
+}
+
+func NewTransformDataset(context *nn.NeuralContext, dataset Dataset, transform Transform) *TransformDataset {
+	return &TransformDataset{
+		dataset:   dataset,
+		transform: transform,
+		context:   context,
+	}
+}
+
+func (dataset *TransformDataset) Len() int {
+	return dataset.dataset.Len()
+}
+
+func (dataset *TransformDataset) Get(index int) (Sample, error) {
+	return dataset.GetWithRandom(index, dataset.context.Random)
+}
+
+func (dataset *TransformDataset) GetWithRandom(index int, random *rand.Rand) (Sample, error) {
+	sample, err := getWithRandom(dataset.dataset, index, random)
+	if err != nil {
+		return Sample{}, err
+	}
+
+	sample.X, err = dataset.transform(random, sample.X)
+	if err != nil {
+		return Sample{}, err
+	}
+
+	return sample, nil
+}
+
+func getWithRandom(dataset Dataset, index int, random *rand.Rand) (Sample, error) {
+	if randomDataset, ok := dataset.(RandomDataset); ok {
+		return randomDataset.GetWithRandom(index, random)
+	}
+
+	return dataset.Get(index)
+}
+
+type raster struct {
+	channels int
+	height   int
+	width    int
+	shape    []int
+	values   []float64
+}
+
+func toRaster(tensor *nn.Tensor) (*raster, error) {
+	shape := tensor.Shape()
+	switch len(shape) {
+	case 2:
+		return &raster{channels: 1, height: shape[0], width: shape[1], shape: shape, values: tensor.Backing.Backing}, nil
+	case 3:
+		return &raster{channels: shape[0], height: shape[1], width: shape[2], shape: shape, values: tensor.Backing.Backing}, nil
+	}
+
+	return nil, fmt.Errorf("expected image tensor of shape [C,H,W] or [H,W], got %v", shape)
+}
+
+func newRasterLike(source *raster, height int, width int) *raster {
+	shape := slices.Clone(source.shape)
+	shape[len(shape)-2] = height
+	shape[len(shape)-1] = width
+
+	return &raster{
+		channels: source.channels,
+		height:   height,
+		width:    width,
+		shape:    shape,
+		values:   make([]float64, source.channels*height*width),
+	}
+}
+
+func (img *raster) clone() *raster {
+	result := newRasterLike(img, img.height, img.width)
+	copy(result.values, img.values)
+
+	return result
+}
+
+func (img *raster) tensor() *nn.Tensor {
+	return nn.NewTensorFromArray(img.values).Reshape(img.shape...)
+}
+
+func (img *raster) at(channel int, y int, x int) float64 {
+	if y < 0 || y >= img.height || x < 0 || x >= img.width {
+		return 0
+	}
+
+	return img.values[(channel*img.height+y)*img.width+x]
+}
+
+func (img *raster) set(channel int, y int, x int, value float64) {
+	img.values[(channel*img.height+y)*img.width+x] = value
+}
+
+func (img *raster) bilinear(channel int, y float64, x float64) float64 {
+	y0, x0 := math.Floor(y), math.Floor(x)
+	dy, dx := y-y0, x-x0
+	top, left := int(y0), int(x0)
+
+	return img.at(channel, top, left)*(1-dy)*(1-dx) +
+		img.at(channel, top, left+1)*(1-dy)*dx +
+		img.at(channel, top+1, left)*dy*(1-dx) +
+		img.at(channel, top+1, left+1)*dy*dx
+}
+
+func (img *raster) remap(source func(y int, x int) (float64, float64)) *raster {
+	result := newRasterLike(img, img.height, img.width)
+	for y := 0; y < img.height; y++ {
+		for x := 0; x < img.width; x++ {
+			sourceY, sourceX := source(y, x)
+			for channel := 0; channel < img.channels; channel++ {
+				result.set(channel, y, x, img.bilinear(channel, sourceY, sourceX))
+			}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,98 @@
+package data
+
+import (
+	"bufio"
+	"fmt"
+	"math/rand"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/Lyx52/micrograd-in-go.git/nn"
+)
+
+// word2vec files start with a "count dimension" header, a 1-dimensional vector line only matches when its
+// token is an integer as well
+func isVectorHeader(fields []string) bool {
+	if len(fields) != 2 {
+		return false
+	}
+
+	for _, field := range fields {
+		if _, err := strconv.Atoi(field); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Lines GloVe / word2vec text vectors up with vocabulary ids, missing tokens are random (or zero without random)
+func LoadWordVectors(filePath string, vocabulary *Vocabulary, random *rand.Rand) ([][]float64, int, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	defer file.Close()
+
+	vectors := make([][]float64, vocabulary.Len())
+	dimension, found, line := 0, 0, 0
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || (line == 1 && isVectorHeader(fields)) {
+			continue
+		}
+
+		if dimension == 0 {
+			dimension = len(fields) - 1
+		}
+
+		if len(fields)-1 != dimension {
+			return nil, 0, fmt.Errorf("%s:%d has dimension %d, expected %d", filePath, line, len(fields)-1, dimension)
+		}
+
+		id, ok := vocabulary.ids[fields[0]]
+		if !ok || vectors[id] != nil {
+			continue
+		}
+
+		vector := make([]float64, dimension)
+		for i, field := range fields[1:] {
+			vector[i], err = strconv.ParseFloat(field, 64)
+			if err != nil {
+				return nil, 0, fmt.Errorf("%s:%d: %w", filePath, line, err)
+			}
+		}
+
+		vectors[id] = vector
+		found++
+	}
+
+	if err = scanner.Err(); err != nil {
+		return nil, 0, err
+	}
+
+	if dimension == 0 {
+		return nil, 0, fmt.Errorf("%s contains no vectors", filePath)
+	}
+
+	for id := range vectors {
+		if vectors[id] != nil {
+			continue
+		}
+
+		vectors[id] = make([]float64, dimension)
+		if random != nil {
+			for i := range vectors[id] {
+				vectors[id][i] = random.NormFloat64()
+			}
+		}
+	}
+
+	nn.Fill(vectors[vocabulary.PadId()], 0)
+	return vectors, found, nil
+}
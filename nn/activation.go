@@ -1,5 +1,11 @@
 package nn
 
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
 type ActivationFunction func(tensor *Tensor) (*Tensor, error)
 
 func NoneActivation(tensor *Tensor) (*Tensor, error) {
@@ -13,3 +19,272 @@ func TanhActivation(tensor *Tensor) (*Tensor, error) {
 func ReluActivation(tensor *Tensor) (*Tensor, error) {
 	return tensor.Relu(), nil
 }
+
+// Applies a scalar function that also reports its derivative, keeping the input shape
+func elementwise(tensor *Tensor, function func(value float64) (float64, float64)) *Tensor {
+	result := make([]float64, len(tensor.Backing.Backing))
+	derivatives := make([]float64, len(result))
+	for i, value := range tensor.Backing.Backing {
+		result[i], derivatives[i] = function(value)
+	}
+
+	return &Tensor{
+		Backing:   NewNArray(result).Reshape(slices.Clone(tensor.Shape())...),
+		Gradients: make([]float64, len(result)),
+		Children:  []*Tensor{tensor},
+		backward: func(parent *Tensor) {
+			for i, derivative := range derivatives {
+				tensor.Gradients[i] += parent.Gradients[i] * derivative
+			}
+		},
+	}
+}
+
+func GeluActivation(tensor *Tensor) (*Tensor, error) {
+	return elementwise(tensor, func(value float64) (float64, float64) {
+		cdf := 0.5 * (1 + math.Erf(value/math.Sqrt2))
+		return value * cdf, cdf + value*math.Exp(-0.5*value*value)/math.Sqrt(2*math.Pi)
+	}), nil
+}
+
+// Stands in for an activation built from invalid arguments, every call reports err
+func invalidActivation(err error) ActivationFunction {
+	return func(tensor *Tensor) (*Tensor, error) {
+		return nil, err
+	}
+}
+
+func sigmoid(value float64) float64 {
+	return 1 / (1 + math.Exp(-value))
+}
+
+func SigmoidActivation(tensor *Tensor) (*Tensor, error) {
+	return elementwise(tensor, func(value float64) (float64, float64) {
+		s := sigmoid(value)
+		return s, s * (1 - s)
+	}), nil
+}
+
+func NewLeakyReluActivation(slope float64) ActivationFunction {
+	return func(tensor *Tensor) (*Tensor, error) {
+		return elementwise(tensor, func(value float64) (float64, float64) {
+			if value > 0 {
+				return value, 1
+			}
+
+			return slope * value, slope
+		}), nil
+	}
+}
+
+var LeakyReluActivation = NewLeakyReluActivation(0.01)
+
+func NewEluActivation(alpha float64) ActivationFunction {
+	return func(tensor *Tensor) (*Tensor, error) {
+		return elementwise(tensor, func(value float64) (float64, float64) {
+			if value > 0 {
+				return value, 1
+			}
+
+			return alpha * (math.Exp(value) - 1), alpha * math.Exp(value)
+		}), nil
+	}
+}
+
+var EluActivation = NewEluActivation(1)
+
+// Scaled ELU with the constants that keep activations self normalizing, pairs with AlphaDropout
+func SeluActivation(tensor *Tensor) (*Tensor, error) {
+	const scale, alpha = 1.0507009873554805, 1.6732632423543772
+	return elementwise(tensor, func(value float64) (float64, float64) {
+		if value > 0 {
+			return scale * value, scale
+		}
+
+		return scale * alpha * (math.Exp(value) - 1), scale * alpha * math.Exp(value)
+	}), nil
+}
+
+// GELU using the tanh approximation
+func GeluTanhActivation(tensor *Tensor) (*Tensor, error) {
+	c := math.Sqrt(2 / math.Pi)
+	return elementwise(tensor, func(value float64) (float64, float64) {
+		t := math.Tanh(c * (value + 0.044715*value*value*value))
+		return 0.5 * value * (1 + t), 0.5*(1+t) + 0.5*value*(1-t*t)*c*(1+3*0.044715*value*value)
+	}), nil
+}
+
+// Also known as Swish
+func SiluActivation(tensor *Tensor) (*Tensor, error) {
+	return elementwise(tensor, func(value float64) (float64, float64) {
+		s := sigmoid(value)
+		return value * s, s + value*s*(1-s)
+	}), nil
+}
+
+// log(1 + exp(beta*x)) / beta, turning linear once beta*x passes 20 to avoid overflow
+func softplus(value float64, beta float64) (float64, float64) {
+	if beta*value > 20 {
+		return value, 1
+	}
+
+	return math.Log1p(math.Exp(beta*value)) / beta, sigmoid(beta * value)
+}
+
+func NewSoftplusActivation(beta float64) ActivationFunction {
+	if beta <= 0 {
+		return invalidActivation(fmt.Errorf("softplus beta %v must be positive", beta))
+	}
+
+	return func(tensor *Tensor) (*Tensor, error) {
+		return elementwise(tensor, func(value float64) (float64, float64) {
+			return softplus(value, beta)
+		}), nil
+	}
+}
+
+var SoftplusActivation = NewSoftplusActivation(1)
+
+func MishActivation(tensor *Tensor) (*Tensor, error) {
+	return elementwise(tensor, func(value float64) (float64, float64) {
+		sp, ds := softplus(value, 1)
+		t := math.Tanh(sp)
+		return value * t, t + value*(1-t*t)*ds
+	}), nil
+}
+
+func NewHardtanhActivation(minimum float64, maximum float64) ActivationFunction {
+	if minimum > maximum {
+		return invalidActivation(fmt.Errorf("hardtanh minimum %v is larger than maximum %v", minimum, maximum))
+	}
+
+	return func(tensor *Tensor) (*Tensor, error) {
+		return elementwise(tensor, func(value float64) (float64, float64) {
+			if value < minimum {
+				return minimum, 0
+			}
+
+			if value > maximum {
+				return maximum, 0
+			}
+
+			return value, 1
+		}), nil
+	}
+}
+
+var HardtanhActivation = NewHardtanhActivation(-1, 1)
+
+func HardSigmoidActivation(tensor *Tensor) (*Tensor, error) {
+	return elementwise(tensor, func(value float64) (float64, float64) {
+		if value <= -3 {
+			return 0, 0
+		}
+
+		if value >= 3 {
+			return 1, 0
+		}
+
+		return value/6 + 0.5, 1.0 / 6
+	}), nil
+}
+
+// Wraps any ActivationFunction as a standalone layer
+type ActivationLayer struct {
+	Activation ActivationFunction
+	context    *NeuralContext
+}
+
+func NewActivationLayer(context *NeuralContext, activation ActivationFunction) *ActivationLayer {
+	return &ActivationLayer{
+		Activation: activation,
+		context:    context,
+	}
+}
+
+func (layer *ActivationLayer) Zerograd() {
+}
+
+func (layer *ActivationLayer) UpdateParameters(updateCallback UpdateTensorFunction) {
+}
+
+func (layer *ActivationLayer) SetTraining(training bool) {
+}
+
+func (layer *ActivationLayer) Execute(tensor *Tensor) (*Tensor, error) {
+	return layer.Activation(tensor)
+}
+
+// Leaky ReLU with a learned slope, either one shared slope or one per channel of [B,C,...] input
+type PReluLayer struct {
+	Weights *Tensor
+	context *NeuralContext
+}
+
+func NewPReluLayer(context *NeuralContext, channels int, initial float64) *PReluLayer {
+	weights := NewTensorEmpty(channels)
+	for i := range weights.Backing.Backing {
+		weights.Backing.Backing[i] = initial
+	}
+
+	return &PReluLayer{
+		Weights: weights,
+		context: context,
+	}
+}
+
+func (layer *PReluLayer) Zerograd() {
+	layer.Weights.Zerograd()
+}
+
+func (layer *PReluLayer) UpdateParameters(updateCallback UpdateTensorFunction) {
+	updateCallback(layer.context, layer.Weights)
+}
+
+func (layer *PReluLayer) SetTraining(training bool) {
+}
+
+func (layer *PReluLayer) Execute(tensor *Tensor) (*Tensor, error) {
+	shape := tensor.Shape()
+	channels := len(layer.Weights.Backing.Backing)
+	inner := 1
+	if channels > 1 {
+		axis := min(1, len(shape)-1)
+		if shape[axis] != channels {
+			return nil, fmt.Errorf("prelu layer expected %d channels on axis %d, got shape %v", channels, axis, shape)
+		}
+
+		if axis+1 < len(shape) {
+			inner = GetTotalElements(shape[axis+1:])
+		}
+	}
+
+	// Copied so an update between the forward and backward pass cannot change the derivative
+	weights, slopes := layer.Weights, slices.Clone(layer.Weights.Backing.Backing)
+	values := tensor.Backing.Backing
+	result := make([]float64, len(values))
+	for i, value := range values {
+		result[i] = value
+		if value <= 0 {
+			result[i] = slopes[i/inner%channels] * value
+		}
+	}
+
+	return &Tensor{
+		Backing:   NewNArray(result).Reshape(slices.Clone(shape)...),
+		Gradients: make([]float64, len(result)),
+		Children:  []*Tensor{tensor, weights},
+		backward: func(parent *Tensor) {
+			for i, gradient := range parent.Gradients {
+				if values[i] > 0 {
+					tensor.Gradients[i] += gradient
+					continue
+				}
+
+				channel := i / inner % channels
+				tensor.Gradients[i] += slopes[channel] * gradient
+				weights.Gradients[channel] += values[i] * gradient
+			}
+		},
+	}, nil
+}
@@ -0,0 +1,97 @@
+package nn
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestActivationGradients(t *testing.T) {
+	cases := []struct {
+		name       string
+		activation ActivationFunction
+	}{
+		{"relu", ReluActivation},
+		{"tanh", TanhActivation},
+		{"sigmoid", SigmoidActivation},
+		{"leaky relu", LeakyReluActivation},
+		{"elu", NewEluActivation(0.5)},
+		{"selu", SeluActivation},
+		{"gelu", GeluActivation},
+		{"gelu tanh", GeluTanhActivation},
+		{"silu", SiluActivation},
+		{"softplus", NewSoftplusActivation(2)},
+		{"mish", MishActivation},
+		{"hardtanh", NewHardtanhActivation(-2, 1.5)},
+		{"hard sigmoid", HardSigmoidActivation},
+	}
+
+	for _, c := range cases {
+		// Spread over [-4,4] so every piece of the piecewise functions is visited
+		input := randomTensor(rand.New(rand.NewSource(1)), 4, 8)
+		for i := range input.Backing.Backing {
+			input.Backing.Backing[i] *= 4
+		}
+
+		checkGradients(t, c.name, []*Tensor{input}, func() (*Tensor, error) {
+			output, err := c.activation(input)
+			if err == nil && len(output.Shape()) != 2 {
+				t.Fatalf("%s: expected the [4 8] input shape to be kept, got %v", c.name, output.Shape())
+			}
+
+			return output, err
+		})
+	}
+}
+
+func TestReluAndTanhScalarGradients(t *testing.T) {
+	for _, value := range []float64{-0.7, 0.4, 1.3} {
+		input := NewTensorFromArray([]float64{value})
+		checkGradients(t, "scalar relu", []*Tensor{input}, func() (*Tensor, error) {
+			return ReluActivation(input)
+		})
+
+		checkGradients(t, "scalar tanh", []*Tensor{input}, func() (*Tensor, error) {
+			return TanhActivation(input)
+		})
+	}
+}
+
+func TestPReluGradients(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	layer := NewPReluLayer(NewNeuralContext(0), 3, 0.25)
+	copy(layer.Weights.Backing.Backing, randomTensor(random, 3).Backing.Backing)
+	input := randomTensor(random, 2, 3, 4)
+	checkGradients(t, "prelu", []*Tensor{input, layer.Weights}, func() (*Tensor, error) {
+		return layer.Execute(input)
+	})
+}
+
+func TestPReluBackwardUsesForwardSlopes(t *testing.T) {
+	layer := NewPReluLayer(NewNeuralContext(0), 1, 0.25)
+	input := NewTensorFromArray([]float64{-2, 3})
+	output, err := layer.Execute(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	layer.Weights.Backing.Backing[0] = 10
+	output.Sum().Backward()
+	if input.Gradients[0] != 0.25 || input.Gradients[1] != 1 {
+		t.Fatalf("expected input gradients [0.25 1], got %v", input.Gradients)
+	}
+}
+
+func TestActivationsRejectInvalidArguments(t *testing.T) {
+	input := NewTensorFromArray([]float64{-1, 0, 1})
+	cases := map[string]ActivationFunction{
+		"softplus zero beta":      NewSoftplusActivation(0),
+		"softplus negative beta":  NewSoftplusActivation(-1),
+		"hardtanh inverted range": NewHardtanhActivation(1, -1),
+	}
+
+	for name, activation := range cases {
+		if _, err := activation(input); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
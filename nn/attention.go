@@ -0,0 +1,248 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+)
+
+type MultiHeadAttentionLayer struct {
+	WeightsQuery  *Tensor
+	WeightsKey    *Tensor
+	WeightsValue  *Tensor
+	WeightsOutput *Tensor
+	BiasQuery     *Tensor
+	BiasKey       *Tensor
+	BiasValue     *Tensor
+	BiasOutput    *Tensor
+	EmbedDim      int
+	Heads         int
+	Causal        bool
+	KeepAttention bool
+	Attention     *Tensor
+	Rotary        *RotaryPositionalEncoding
+	dropout       *DropoutLayer
+	context       *NeuralContext
+}
+
+func NewMultiHeadAttentionLayer(context *NeuralContext, embedDim int, heads int, dropout float64, useBias bool, causal bool) *MultiHeadAttentionLayer {
+	if heads <= 0 || embedDim%heads != 0 {
+		panic(fmt.Sprintf("attention embedding size %d must be divisible by heads %d", embedDim, heads))
+	}
+
+	projectionBound := math.Sqrt(6 / float64(2*embedDim))
+	outputBound := 1 / math.Sqrt(float64(embedDim))
+	layer := &MultiHeadAttentionLayer{
+		WeightsQuery:  NewTensorUniform(embedDim*embedDim, -projectionBound, projectionBound, context.Random).Reshape(embedDim, embedDim),
+		WeightsKey:    NewTensorUniform(embedDim*embedDim, -projectionBound, projectionBound, context.Random).Reshape(embedDim, embedDim),
+		WeightsValue:  NewTensorUniform(embedDim*embedDim, -projectionBound, projectionBound, context.Random).Reshape(embedDim, embedDim),
+		WeightsOutput: NewTensorUniform(embedDim*embedDim, -outputBound, outputBound, context.Random).Reshape(embedDim, embedDim),
+		EmbedDim:      embedDim,
+		Heads:         heads,
+		Causal:        causal,
+		dropout:       NewDropoutLayer(context, dropout),
+		context:       context,
+	}
+
+	if useBias {
+		layer.BiasQuery = NewTensorEmpty(embedDim)
+		layer.BiasKey = NewTensorEmpty(embedDim)
+		layer.BiasValue = NewTensorEmpty(embedDim)
+		layer.BiasOutput = NewTensorEmpty(embedDim)
+	}
+
+	return layer
+}
+
+func (layer *MultiHeadAttentionLayer) UsesBias() bool {
+	return layer.BiasQuery != nil
+}
+
+func (layer *MultiHeadAttentionLayer) parameters() []*Tensor {
+	parameters := []*Tensor{layer.WeightsQuery, layer.WeightsKey, layer.WeightsValue, layer.WeightsOutput}
+	if layer.UsesBias() {
+		parameters = append(parameters, layer.BiasQuery, layer.BiasKey, layer.BiasValue, layer.BiasOutput)
+	}
+
+	return parameters
+}
+
+func (layer *MultiHeadAttentionLayer) Zerograd() {
+	for _, parameter := range layer.parameters() {
+		parameter.Zerograd()
+	}
+}
+
+func (layer *MultiHeadAttentionLayer) UpdateParameters(updateCallback UpdateTensorFunction) {
+	for _, parameter := range layer.parameters() {
+		updateCallback(layer.context, parameter)
+	}
+}
+
+func (layer *MultiHeadAttentionLayer) SetTraining(training bool) {
+	layer.dropout.SetTraining(training)
+}
+
+// Projects [B,L,E] input and splits it into heads as [B,heads,L,E/heads]
+func (layer *MultiHeadAttentionLayer) project(tensor *Tensor, weights *Tensor, bias *Tensor, batchSize int, length int) (*Tensor, error) {
+	projected, err := Linear(tensor, weights, bias)
+	if err != nil {
+		return nil, err
+	}
+
+	projected.Reshape(batchSize, length, layer.Heads, layer.EmbedDim/layer.Heads)
+	return Transpose(projected, 1, 2)
+}
+
+// Attends query [B,T,E] over key and value [B,S,E] (or the unbatched [T,E] / [S,E] forms). The padding
+// mask is [B,S] with 1 for real positions and 0 for padding, matching the mask of a padded data batch.
+// Returns the [B,T,E] output along with the [B,heads,T,S] attention weights before dropout
+func (layer *MultiHeadAttentionLayer) Forward(query *Tensor, key *Tensor, value *Tensor, paddingMask *Tensor) (*Tensor, *Tensor, error) {
+	queryShape, keyShape, valueShape := query.Shape(), key.Shape(), value.Shape()
+	batched := len(queryShape) == 3
+	for _, shape := range [][]int{queryShape, keyShape, valueShape} {
+		if len(shape) != len(queryShape) || (len(shape) != 2 && len(shape) != 3) || shape[len(shape)-1] != layer.EmbedDim {
+			return nil, nil, fmt.Errorf("attention expected query, key and value of shape [B,L,%d] or [L,%d], got %v, %v and %v", layer.EmbedDim, layer.EmbedDim, queryShape, keyShape, valueShape)
+		}
+	}
+
+	batchSize, targetLength, sourceLength := 1, queryShape[0], keyShape[0]
+	if batched {
+		batchSize, targetLength, sourceLength = queryShape[0], queryShape[1], keyShape[1]
+		if keyShape[0] != batchSize || valueShape[0] != batchSize {
+			return nil, nil, fmt.Errorf("attention batch sizes of %v, %v and %v do not match", queryShape, keyShape, valueShape)
+		}
+	}
+
+	if valueShape[len(valueShape)-2] != sourceLength {
+		return nil, nil, fmt.Errorf("attention key %v and value %v lengths do not match", keyShape, valueShape)
+	}
+
+	if paddingMask != nil && len(paddingMask.Backing.Backing) != batchSize*sourceLength {
+		return nil, nil, fmt.Errorf("attention expected padding mask of shape [%d,%d], got %v", batchSize, sourceLength, paddingMask.Shape())
+	}
+
+	q, err := layer.project(query, layer.WeightsQuery, layer.BiasQuery, batchSize, targetLength)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	k, err := layer.project(key, layer.WeightsKey, layer.BiasKey, batchSize, sourceLength)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	v, err := layer.project(value, layer.WeightsValue, layer.BiasValue, batchSize, sourceLength)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Rotary encodings act on every head of the queries and keys, so they need the head dimension
+	if layer.Rotary != nil {
+		q, err = layer.Rotary.Execute(q)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		k, err = layer.Rotary.Execute(k)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+
+	k, err = Transpose(k, 2, 3)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	scores, err := MatMul(q, k)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	masked := func(index int) bool {
+		source := index % sourceLength
+		target := index / sourceLength % targetLength
+		batch := index / (sourceLength * targetLength * layer.Heads)
+		if layer.Causal && source > target {
+			return true
+		}
+
+		return paddingMask != nil && paddingMask.Backing.Backing[batch*sourceLength+source] == 0
+	}
+
+	attention := MaskedSoftmax(scores, 1/math.Sqrt(float64(layer.EmbedDim/layer.Heads)), masked)
+	dropped, err := layer.dropout.Execute(attention)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	attended, err := MatMul(dropped, v)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	attended, err = Transpose(attended, 1, 2)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	output, err := Linear(attended.Reshape(batchSize, targetLength, layer.EmbedDim), layer.WeightsOutput, layer.BiasOutput)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if !batched {
+		output.Reshape(targetLength, layer.EmbedDim)
+		attention.Reshape(layer.Heads, targetLength, sourceLength)
+	}
+
+	return output, attention, nil
+}
+
+// Self attention of the input with itself, the weights are kept in Attention when KeepAttention is set
+func (layer *MultiHeadAttentionLayer) Execute(tensor *Tensor) (*Tensor, error) {
+	output, attention, err := layer.Forward(tensor, tensor, tensor, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	if layer.KeepAttention {
+		layer.Attention = attention
+	}
+
+	return output, nil
+}
+
+// Reads MainTensor as the query, optional KeyTensor and ValueTensor default to the query and key, a
+// MaskTensor is used as the padding mask. Returns MainTensor and AttentionTensor
+func (layer *MultiHeadAttentionLayer) ExecuteMany(inputs NamedTensors) (NamedTensors, error) {
+	query, err := inputs.Require(MainTensor)
+	if err != nil {
+		return nil, err
+	}
+
+	key, ok := inputs[KeyTensor]
+	if !ok {
+		key = query
+	}
+
+	value, ok := inputs[ValueTensor]
+	if !ok {
+		value = key
+	}
+
+	if key == nil || value == nil {
+		return nil, fmt.Errorf("missing %q or %q input", KeyTensor, ValueTensor)
+	}
+
+	output, attention, err := layer.Forward(query, key, value, inputs[MaskTensor])
+	if err != nil {
+		return nil, err
+	}
+
+	if layer.KeepAttention {
+		layer.Attention = attention
+	}
+
+	return NamedTensors{MainTensor: output, AttentionTensor: attention}, nil
+}
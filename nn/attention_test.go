@@ -0,0 +1,74 @@
+package nn
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestAttentionOperationGradients(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	first, second := randomTensor(random, 2, 3, 4), randomTensor(random, 2, 4, 5)
+	checkGradients(t, "matmul", []*Tensor{first, second}, func() (*Tensor, error) {
+		return MatMul(first, second)
+	})
+
+	checkGradients(t, "transpose", []*Tensor{first}, func() (*Tensor, error) {
+		return Transpose(first, 0, 2)
+	})
+
+	weights, bias := randomTensor(random, 5, 4), randomTensor(random, 5)
+	checkGradients(t, "linear", []*Tensor{first, weights, bias}, func() (*Tensor, error) {
+		return Linear(first, weights, bias)
+	})
+
+	// The last row is masked entirely and has to stay zero without passing gradient on
+	scores := randomTensor(random, 3, 4)
+	checkGradients(t, "masked softmax", []*Tensor{scores}, func() (*Tensor, error) {
+		return MaskedSoftmax(scores, 0.5, func(index int) bool {
+			return index%4 == 3 || index >= 8
+		}), nil
+	})
+}
+
+func TestMultiHeadAttentionGradients(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	mask := NewTensorFromArray([]float64{1, 1, 0, 1, 1, 1, 1, 0}).Reshape(2, 4)
+	for _, causal := range []bool{false, true} {
+		layer := NewMultiHeadAttentionLayer(NewNeuralContext(0), 4, 2, 0, true, causal)
+		for _, bias := range []*Tensor{layer.BiasQuery, layer.BiasKey, layer.BiasValue, layer.BiasOutput} {
+			copy(bias.Backing.Backing, randomTensor(random, 4).Backing.Backing)
+		}
+
+		query, key, value := randomTensor(random, 2, 4, 4), randomTensor(random, 2, 4, 4), randomTensor(random, 2, 4, 4)
+		parameters := append([]*Tensor{query, key, value}, layer.parameters()...)
+		checkGradients(t, "attention", parameters, func() (*Tensor, error) {
+			output, _, err := layer.Forward(query, key, value, mask)
+			return output, err
+		})
+	}
+}
+
+func TestMultiHeadAttentionExecuteManyReadsKeyAndValue(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	layer := NewMultiHeadAttentionLayer(NewNeuralContext(0), 4, 2, 0, true, false)
+	query, key, value := randomTensor(random, 2, 3, 4), randomTensor(random, 2, 5, 4), randomTensor(random, 2, 5, 4)
+	expected, _, err := layer.Forward(query, key, value, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	outputs, err := layer.ExecuteMany(NamedTensors{MainTensor: query, KeyTensor: key, ValueTensor: value})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for i, value := range expected.Backing.Backing {
+		if outputs[MainTensor].Backing.Backing[i] != value {
+			t.Fatalf("output %d is %v, Forward gives %v", i, outputs[MainTensor].Backing.Backing[i], value)
+		}
+	}
+
+	if _, err := layer.ExecuteMany(NamedTensors{MainTensor: query, KeyTensor: nil}); err == nil {
+		t.Error("expected an error for a nil key")
+	}
+}
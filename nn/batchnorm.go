@@ -0,0 +1,203 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+type BatchNormLayer struct {
+	Weights         *Tensor
+	Bias            *Tensor
+	RunningMean     []float64
+	RunningVariance []float64
+	Momentum        float64
+	Epsilon         float64
+	spatialDims     []int
+	training        bool
+	context         *NeuralContext
+}
+
+func newBatchNormLayer(context *NeuralContext, features int, momentum float64, epsilon float64, affine bool, spatialDims ...int) *BatchNormLayer {
+	if features <= 0 {
+		panic(fmt.Sprintf("batch norm features must be positive, got %d", features))
+	}
+
+	layer := &BatchNormLayer{
+		RunningMean:     make([]float64, features),
+		RunningVariance: Fill(make([]float64, features), 1),
+		Momentum:        momentum,
+		Epsilon:         epsilon,
+		spatialDims:     spatialDims,
+		training:        true,
+		context:         context,
+	}
+
+	if affine {
+		layer.Weights = NewTensorWithValue(features, 1)
+		layer.Bias = NewTensorEmpty(features)
+	}
+
+	return layer
+}
+
+// Accepts [B,C] or [B,C,L] input, statistics are taken per feature over the batch and length
+func NewBatchNorm1DLayer(context *NeuralContext, features int, momentum float64, epsilon float64, affine bool) *BatchNormLayer {
+	return newBatchNormLayer(context, features, momentum, epsilon, affine, 0, 1)
+}
+
+// Accepts [B,C,H,W] input, statistics are taken per channel over the batch and both spatial dimensions
+func NewBatchNorm2DLayer(context *NeuralContext, channels int, momentum float64, epsilon float64, affine bool) *BatchNormLayer {
+	return newBatchNormLayer(context, channels, momentum, epsilon, affine, 2)
+}
+
+func (layer *BatchNormLayer) Features() int {
+	return len(layer.RunningMean)
+}
+
+func (layer *BatchNormLayer) IsAffine() bool {
+	return layer.Weights != nil
+}
+
+func (layer *BatchNormLayer) IsTraining() bool {
+	return layer.training
+}
+
+func (layer *BatchNormLayer) ResetRunningStatistics() {
+	Fill(layer.RunningMean, 0)
+	Fill(layer.RunningVariance, 1)
+}
+
+func (layer *BatchNormLayer) Zerograd() {
+	if layer.IsAffine() {
+		layer.Weights.Zerograd()
+		layer.Bias.Zerograd()
+	}
+}
+
+func (layer *BatchNormLayer) UpdateParameters(updateCallback UpdateTensorFunction) {
+	if layer.IsAffine() {
+		updateCallback(layer.context, layer.Weights)
+		updateCallback(layer.context, layer.Bias)
+	}
+}
+
+func (layer *BatchNormLayer) SetTraining(training bool) {
+	layer.training = training
+}
+
+func (layer *BatchNormLayer) Execute(tensor *Tensor) (*Tensor, error) {
+	shape := tensor.Shape()
+	// Batch dimension, channel dimension and then the allowed number of spatial dimensions
+	if len(shape) < 2 || !slices.Contains(layer.spatialDims, len(shape)-2) {
+		return nil, fmt.Errorf("batch norm expected input with %v spatial dimensions after [B,C], got %v", layer.spatialDims, shape)
+	}
+
+	batchSize, channels := shape[0], shape[1]
+	if channels != layer.Features() {
+		return nil, fmt.Errorf("batch norm expected %d channels, got %d", layer.Features(), channels)
+	}
+
+	inner := GetTotalElements(shape[2:])
+	if len(shape) == 2 {
+		inner = 1
+	}
+
+	count := batchSize * inner
+	if layer.training && count < 2 {
+		return nil, fmt.Errorf("batch norm expected more than one value per channel in training mode, got input %v", shape)
+	}
+
+	values := tensor.Backing.Backing
+	forEach := func(channel int, callback func(index int)) {
+		for b := 0; b < batchSize; b++ {
+			base := (b*channels + channel) * inner
+			for i := base; i < base+inner; i++ {
+				callback(i)
+			}
+		}
+	}
+
+	mean, inverseStd := make([]float64, channels), make([]float64, channels)
+	for c := 0; c < channels; c++ {
+		if !layer.training {
+			mean[c] = layer.RunningMean[c]
+			inverseStd[c] = 1 / math.Sqrt(layer.RunningVariance[c]+layer.Epsilon)
+			continue
+		}
+
+		sum := 0.0
+		forEach(c, func(index int) {
+			sum += values[index]
+		})
+		mean[c] = sum / float64(count)
+
+		squares := 0.0
+		forEach(c, func(index int) {
+			squares += (values[index] - mean[c]) * (values[index] - mean[c])
+		})
+		inverseStd[c] = 1 / math.Sqrt(squares/float64(count)+layer.Epsilon)
+
+		// Running variance tracks the unbiased estimate while the batch is normalized with the biased one
+		layer.RunningMean[c] = (1-layer.Momentum)*layer.RunningMean[c] + layer.Momentum*mean[c]
+		layer.RunningVariance[c] = (1-layer.Momentum)*layer.RunningVariance[c] + layer.Momentum*squares/float64(count-1)
+	}
+
+	normalized := make([]float64, len(values))
+	result := make([]float64, len(values))
+	for c := 0; c < channels; c++ {
+		scale, shift := 1.0, 0.0
+		if layer.IsAffine() {
+			scale, shift = layer.Weights.Backing.Backing[c], layer.Bias.Backing.Backing[c]
+		}
+
+		forEach(c, func(index int) {
+			normalized[index] = (values[index] - mean[c]) * inverseStd[c]
+			result[index] = scale*normalized[index] + shift
+		})
+	}
+
+	children := []*Tensor{tensor}
+	if layer.IsAffine() {
+		children = append(children, layer.Weights, layer.Bias)
+	}
+
+	training, weights, bias := layer.training, layer.Weights, layer.Bias
+	return &Tensor{
+		Backing:   NewNArray(result).Reshape(shape...),
+		Gradients: make([]float64, len(result)),
+		Children:  children,
+		backward: func(parent *Tensor) {
+			for c := 0; c < channels; c++ {
+				scale := 1.0
+				if weights != nil {
+					scale = weights.Backing.Backing[c]
+				}
+
+				sum, dot := 0.0, 0.0
+				forEach(c, func(index int) {
+					sum += parent.Gradients[index]
+					dot += parent.Gradients[index] * normalized[index]
+				})
+
+				if weights != nil {
+					weights.Gradients[c] += dot
+					bias.Gradients[c] += sum
+				}
+
+				if !training {
+					forEach(c, func(index int) {
+						tensor.Gradients[index] += parent.Gradients[index] * scale * inverseStd[c]
+					})
+					continue
+				}
+
+				// Batch statistics depend on every input so the mean and variance paths are folded in analytically
+				n := float64(count)
+				forEach(c, func(index int) {
+					tensor.Gradients[index] += scale * inverseStd[c] / n * (n*parent.Gradients[index] - sum - normalized[index]*dot)
+				})
+			}
+		},
+	}, nil
+}
@@ -0,0 +1,38 @@
+package nn
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestBatchNormGradients(t *testing.T) {
+	context := NewNeuralContext(0)
+	cases := []struct {
+		name  string
+		layer *BatchNormLayer
+		dims  []int
+	}{
+		{"1d features", NewBatchNorm1DLayer(context, 3, 0.1, 1e-5, true), []int{4, 3}},
+		{"1d sequence", NewBatchNorm1DLayer(context, 2, 0.1, 1e-5, true), []int{3, 2, 4}},
+		{"2d", NewBatchNorm2DLayer(context, 2, 0.1, 1e-5, true), []int{2, 2, 3, 3}},
+		{"2d without affine", NewBatchNorm2DLayer(context, 2, 0.1, 1e-5, false), []int{2, 2, 2, 3}},
+	}
+
+	for _, c := range cases {
+		random := rand.New(rand.NewSource(1))
+		input := randomTensor(random, c.dims...)
+		parameters := []*Tensor{input}
+		if c.layer.IsAffine() {
+			c.layer.Weights = randomTensor(random, c.layer.Features())
+			c.layer.Bias = randomTensor(random, c.layer.Features())
+			parameters = append(parameters, c.layer.Weights, c.layer.Bias)
+		}
+
+		for _, training := range []bool{true, false} {
+			c.layer.SetTraining(training)
+			checkGradients(t, c.name, parameters, func() (*Tensor, error) {
+				return c.layer.Execute(input)
+			})
+		}
+	}
+}
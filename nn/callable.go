@@ -1,9 +1,61 @@
 package nn
 
+import "fmt"
+
 type ICallable interface {
 	Execute(tensor *Tensor) (*Tensor, error)
 	Zerograd()
 	UpdateParameters(updateCallback UpdateTensorFunction)
+	SetTraining(training bool)
 }
 
 type UpdateTensorFunction func(*NeuralContext, *Tensor)
+
+type NamedTensors map[string]*Tensor
+
+// Well known names, single tensor layers read and write MainTensor when wired by name
+const (
+	MainTensor       = "main"
+	MaskTensor       = "mask"
+	MemoryTensor     = "memory"
+	MemoryMaskTensor = "memory_mask"
+	AttentionTensor  = "attention"
+	KeyTensor        = "key"
+	ValueTensor      = "value"
+)
+
+// Looks up a tensor the caller has to provide
+func (tensors NamedTensors) Require(name string) (*Tensor, error) {
+	tensor, ok := tensors[name]
+	if !ok || tensor == nil {
+		return nil, fmt.Errorf("missing %q input", name)
+	}
+
+	return tensor, nil
+}
+
+// Layers taking side inputs or producing several outputs, the returned tensors are added to the named
+// tensors of a Module replacing any with the same name
+type IMultiCallable interface {
+	ICallable
+	ExecuteMany(inputs NamedTensors) (NamedTensors, error)
+}
+
+// Runs a layer on named tensors, single tensor layers read and write MainTensor
+func ExecuteMany(layer ICallable, inputs NamedTensors) (NamedTensors, error) {
+	if multi, ok := layer.(IMultiCallable); ok {
+		return multi.ExecuteMany(inputs)
+	}
+
+	tensor, err := inputs.Require(MainTensor)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := layer.Execute(tensor)
+	if err != nil {
+		return nil, err
+	}
+
+	return NamedTensors{MainTensor: result}, nil
+}
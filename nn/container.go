@@ -0,0 +1,402 @@
+package nn
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+type MergeMode int
+
+const (
+	SumMerge MergeMode = iota
+	ConcatMerge
+)
+
+func merge(tensors []*Tensor, mode MergeMode, axis int) (*Tensor, error) {
+	if len(tensors) == 0 {
+		return nil, errors.New("cannot merge an empty list of tensors")
+	}
+
+	if mode == ConcatMerge {
+		return Concat(tensors, axis)
+	}
+
+	shape := slices.Clone(tensors[0].Shape())
+	result := tensors[0]
+	for _, tensor := range tensors[1:] {
+		if !slices.Equal(tensor.Shape(), shape) {
+			return nil, fmt.Errorf("cannot sum tensor of shape %v with tensor of shape %v", tensor.Shape(), shape)
+		}
+
+		sum, err := TensorAdd(result, tensor)
+		if err != nil {
+			return nil, err
+		}
+
+		result = sum.Reshape(slices.Clone(shape)...)
+	}
+
+	return result, nil
+}
+
+// Each layer once in order of first appearance, containers holding one instance twice tie its weights
+// and must not update it twice
+func uniqueLayers(layers []ICallable) []ICallable {
+	unique := make([]ICallable, 0, len(layers))
+	seen := make(map[ICallable]bool, len(layers))
+	for _, layer := range layers {
+		if layer != nil && !seen[layer] {
+			seen[layer] = true
+			unique = append(unique, layer)
+		}
+	}
+
+	return unique
+}
+
+type ResidualLayer struct {
+	Inner    ICallable
+	Shortcut ICallable
+	context  *NeuralContext
+}
+
+// Computes inner(x) + shortcut(x), a nil shortcut is the identity
+func NewResidualLayer(context *NeuralContext, inner ICallable, shortcut ICallable) *ResidualLayer {
+	return &ResidualLayer{
+		Inner:    inner,
+		Shortcut: shortcut,
+		context:  context,
+	}
+}
+
+func (layer *ResidualLayer) sublayers() []ICallable {
+	return uniqueLayers([]ICallable{layer.Inner, layer.Shortcut})
+}
+
+func (layer *ResidualLayer) Zerograd() {
+	for _, sublayer := range layer.sublayers() {
+		sublayer.Zerograd()
+	}
+}
+
+func (layer *ResidualLayer) UpdateParameters(updateCallback UpdateTensorFunction) {
+	for _, sublayer := range layer.sublayers() {
+		sublayer.UpdateParameters(updateCallback)
+	}
+}
+
+func (layer *ResidualLayer) SetTraining(training bool) {
+	for _, sublayer := range layer.sublayers() {
+		sublayer.SetTraining(training)
+	}
+}
+
+func (layer *ResidualLayer) Execute(tensor *Tensor) (*Tensor, error) {
+	output, err := layer.Inner.Execute(tensor)
+	if err != nil {
+		return nil, err
+	}
+
+	skip := tensor
+	if layer.Shortcut != nil {
+		skip, err = layer.Shortcut.Execute(tensor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return merge([]*Tensor{output, skip}, SumMerge, 0)
+}
+
+type ParallelLayer struct {
+	Branches []ICallable
+	Mode     MergeMode
+	Axis     int
+	context  *NeuralContext
+}
+
+// Feeds the same input to every branch and sums or concatenates the results, axis only matters for concat
+func NewParallelLayer(context *NeuralContext, mode MergeMode, axis int, branches ...ICallable) *ParallelLayer {
+	if len(branches) == 0 {
+		panic("parallel layer needs at least one branch")
+	}
+
+	return &ParallelLayer{
+		Branches: branches,
+		Mode:     mode,
+		Axis:     axis,
+		context:  context,
+	}
+}
+
+func (layer *ParallelLayer) Zerograd() {
+	for _, branch := range uniqueLayers(layer.Branches) {
+		branch.Zerograd()
+	}
+}
+
+func (layer *ParallelLayer) UpdateParameters(updateCallback UpdateTensorFunction) {
+	for _, branch := range uniqueLayers(layer.Branches) {
+		branch.UpdateParameters(updateCallback)
+	}
+}
+
+func (layer *ParallelLayer) SetTraining(training bool) {
+	for _, branch := range uniqueLayers(layer.Branches) {
+		branch.SetTraining(training)
+	}
+}
+
+func (layer *ParallelLayer) Execute(tensor *Tensor) (*Tensor, error) {
+	outputs := make([]*Tensor, len(layer.Branches))
+	var err error
+	for i, branch := range layer.Branches {
+		outputs[i], err = branch.Execute(tensor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return merge(outputs, layer.Mode, layer.Axis)
+}
+
+// Bindings map the tensor names a layer reads to graph values, a bound node passes them to the layer
+// unmerged and exposes every output as "node.output" with MainTensor also under the node name
+type GraphNode struct {
+	Name     string
+	Layer    ICallable
+	Inputs   []string
+	Bindings map[string]string
+	Mode     MergeMode
+	Axis     int
+}
+
+// Layers wired by name, every node reads the named outputs of earlier nodes or graph inputs. Nodes with
+// several inputs merge them first, and a node without a layer only merges
+type GraphModule struct {
+	InputNames []string
+	Nodes      []*GraphNode
+	Output     string
+	Outputs    []string
+	context    *NeuralContext
+}
+
+func NewGraphModule(context *NeuralContext, inputNames ...string) *GraphModule {
+	if len(inputNames) == 0 {
+		inputNames = []string{"input"}
+	}
+
+	return &GraphModule{
+		InputNames: inputNames,
+		context:    context,
+	}
+}
+
+func (graph *GraphModule) hasName(name string) bool {
+	if slices.Contains(graph.InputNames, name) {
+		return true
+	}
+
+	node, _, found := strings.Cut(name, ".")
+	return slices.ContainsFunc(graph.Nodes, func(other *GraphNode) bool {
+		return other.Name == name || (found && other.Name == node && other.Bindings != nil)
+	})
+}
+
+func (graph *GraphModule) AddNode(node *GraphNode) *GraphModule {
+	if graph.hasName(node.Name) {
+		panic(fmt.Sprintf("graph already has a node or input named %q", node.Name))
+	}
+
+	if strings.Contains(node.Name, ".") {
+		panic(fmt.Sprintf("graph node name %q cannot contain a dot", node.Name))
+	}
+
+	if len(node.Inputs) == 0 {
+		panic(fmt.Sprintf("graph node %q has no inputs", node.Name))
+	}
+
+	if node.Bindings != nil && node.Layer == nil {
+		panic(fmt.Sprintf("graph node %q binds named inputs but has no layer", node.Name))
+	}
+
+	for _, input := range node.Inputs {
+		if !graph.hasName(input) {
+			panic(fmt.Sprintf("graph node %q reads unknown input %q", node.Name, input))
+		}
+	}
+
+	graph.Nodes = append(graph.Nodes, node)
+	graph.Output = node.Name
+
+	return graph
+}
+
+// Adds a layer reading the given inputs, several inputs are summed before the layer sees them
+func (graph *GraphModule) Add(name string, layer ICallable, inputs ...string) *GraphModule {
+	return graph.AddNode(&GraphNode{Name: name, Layer: layer, Inputs: inputs, Mode: SumMerge})
+}
+
+// Adds a layer reading several named tensors, e.g. {MainTensor: "decoder", MemoryTensor: "encoder"}
+func (graph *GraphModule) AddBound(name string, layer ICallable, bindings map[string]string) *GraphModule {
+	inputs := make([]string, 0, len(bindings))
+	for _, input := range bindings {
+		inputs = append(inputs, input)
+	}
+
+	return graph.AddNode(&GraphNode{Name: name, Layer: layer, Inputs: inputs, Bindings: bindings})
+}
+
+func (graph *GraphModule) AddMerge(name string, mode MergeMode, axis int, inputs ...string) *GraphModule {
+	return graph.AddNode(&GraphNode{Name: name, Inputs: inputs, Mode: mode, Axis: axis})
+}
+
+func (graph *GraphModule) layers() []ICallable {
+	layers := make([]ICallable, len(graph.Nodes))
+	for i, node := range graph.Nodes {
+		layers[i] = node.Layer
+	}
+
+	return uniqueLayers(layers)
+}
+
+func (graph *GraphModule) Zerograd() {
+	for _, layer := range graph.layers() {
+		layer.Zerograd()
+	}
+}
+
+func (graph *GraphModule) UpdateParameters(updateCallback UpdateTensorFunction) {
+	for _, layer := range graph.layers() {
+		layer.UpdateParameters(updateCallback)
+	}
+}
+
+func (graph *GraphModule) SetTraining(training bool) {
+	for _, layer := range graph.layers() {
+		layer.SetTraining(training)
+	}
+}
+
+// Runs every node in insertion order and returns all named values, graph inputs included
+func (graph *GraphModule) Evaluate(inputs NamedTensors) (NamedTensors, error) {
+	values := make(NamedTensors, len(graph.InputNames)+len(graph.Nodes))
+	for _, name := range graph.InputNames {
+		tensor, err := inputs.Require(name)
+		if err != nil {
+			return nil, fmt.Errorf("graph: %w", err)
+		}
+
+		values[name] = tensor
+	}
+
+	for _, node := range graph.Nodes {
+		if node.Bindings != nil {
+			bound := make(NamedTensors, len(node.Bindings))
+			for name, input := range node.Bindings {
+				tensor, ok := values[input]
+				if !ok {
+					return nil, fmt.Errorf("graph node %q reads missing output %q", node.Name, input)
+				}
+
+				bound[name] = tensor
+			}
+
+			outputs, err := ExecuteMany(node.Layer, bound)
+			if err != nil {
+				return nil, fmt.Errorf("graph node %q: %w", node.Name, err)
+			}
+
+			for name, tensor := range outputs {
+				values[node.Name+"."+name] = tensor
+			}
+
+			if main, ok := outputs[MainTensor]; ok {
+				values[node.Name] = main
+			}
+
+			continue
+		}
+
+		tensors := make([]*Tensor, len(node.Inputs))
+		for i, input := range node.Inputs {
+			tensor, ok := values[input]
+			if !ok {
+				return nil, fmt.Errorf("graph node %q reads missing output %q", node.Name, input)
+			}
+
+			tensors[i] = tensor
+		}
+
+		result, err := merge(tensors, node.Mode, node.Axis)
+		if err != nil {
+			return nil, fmt.Errorf("graph node %q: %w", node.Name, err)
+		}
+
+		if node.Layer != nil {
+			result, err = node.Layer.Execute(result)
+			if err != nil {
+				return nil, fmt.Errorf("graph node %q: %w", node.Name, err)
+			}
+		}
+
+		values[node.Name] = result
+	}
+
+	return values, nil
+}
+
+func (graph *GraphModule) ExecuteNamed(inputs NamedTensors) (*Tensor, error) {
+	values, err := graph.Evaluate(inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	output, ok := values[graph.Output]
+	if !ok {
+		return nil, fmt.Errorf("graph output %q is not a node or input", graph.Output)
+	}
+
+	return output, nil
+}
+
+// Reads the graph inputs by name, a single input graph falls back to MainTensor. Returns the output as
+// MainTensor along with every value listed in Outputs
+func (graph *GraphModule) ExecuteMany(inputs NamedTensors) (NamedTensors, error) {
+	if _, ok := inputs[graph.InputNames[0]]; !ok && len(graph.InputNames) == 1 {
+		main, err := inputs.Require(MainTensor)
+		if err != nil {
+			return nil, err
+		}
+
+		inputs = NamedTensors{graph.InputNames[0]: main}
+	}
+
+	values, err := graph.Evaluate(inputs)
+	if err != nil {
+		return nil, err
+	}
+
+	outputs := make(NamedTensors, len(graph.Outputs)+1)
+	for _, name := range append([]string{graph.Output}, graph.Outputs...) {
+		tensor, ok := values[name]
+		if !ok {
+			return nil, fmt.Errorf("graph output %q is not a node or input", name)
+		}
+
+		outputs[name] = tensor
+	}
+
+	outputs[MainTensor] = outputs[graph.Output]
+	return outputs, nil
+}
+
+func (graph *GraphModule) Execute(tensor *Tensor) (*Tensor, error) {
+	if len(graph.InputNames) != 1 {
+		return nil, fmt.Errorf("graph with inputs %v needs ExecuteNamed", graph.InputNames)
+	}
+
+	return graph.ExecuteNamed(NamedTensors{graph.InputNames[0]: tensor})
+}
@@ -0,0 +1,42 @@
+package nn
+
+import "testing"
+
+func TestContainersUpdateSharedLayersOnce(t *testing.T) {
+	context := NewNeuralContext(0)
+	shared := NewLinearLayer(context, 2, 2, true, NoneActivation)
+	other := NewLinearLayer(context, 2, 2, false, NoneActivation)
+	graph := NewGraphModule(context).Add("first", shared, "input").Add("second", shared, "first").Add("third", other, "second")
+	containers := map[string]ICallable{
+		"residual": NewResidualLayer(context, shared, shared),
+		"parallel": NewParallelLayer(context, SumMerge, 0, shared, other, shared),
+		"graph":    graph,
+	}
+
+	for name, container := range containers {
+		updates := make(map[*Tensor]int)
+		container.UpdateParameters(func(context *NeuralContext, tensor *Tensor) {
+			updates[tensor]++
+		})
+
+		for tensor, count := range updates {
+			if count != 1 {
+				t.Errorf("%s: parameter of shape %v updated %d times", name, tensor.Shape(), count)
+			}
+		}
+
+		if len(updates) == 0 {
+			t.Errorf("%s: no parameters updated", name)
+		}
+	}
+}
+
+func TestGraphRejectsBoundNodeWithoutLayer(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected AddBound with a nil layer to panic")
+		}
+	}()
+
+	NewGraphModule(NewNeuralContext(0)).AddBound("attention", nil, map[string]string{MainTensor: "input"})
+}
@@ -0,0 +1,371 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+type PaddingMode int
+
+const (
+	ZeroPadding PaddingMode = iota
+	ReflectPadding
+	ReplicatePadding
+)
+
+type convolution struct {
+	inChannels  int
+	outChannels int
+	groups      int
+	kernel      []int
+	stride      []int
+	padBefore   []int
+	padAfter    []int
+	dilation    []int
+	paddingMode PaddingMode
+}
+
+func repeatInt(value int, count int) []int {
+	return Fill(make([]int, count), value)
+}
+
+func newConvolution(inChannels int, outChannels int, kernel []int, stride []int, padding []int, dilation []int, groups int, paddingMode PaddingMode) (convolution, error) {
+	if inChannels <= 0 || outChannels <= 0 {
+		return convolution{}, fmt.Errorf("convolution channels %d -> %d must be positive", inChannels, outChannels)
+	}
+
+	if groups <= 0 || inChannels%groups != 0 || outChannels%groups != 0 {
+		return convolution{}, fmt.Errorf("convolution channels %d -> %d are not divisible into %d groups", inChannels, outChannels, groups)
+	}
+
+	for i := range kernel {
+		if kernel[i] <= 0 || stride[i] <= 0 || dilation[i] <= 0 {
+			return convolution{}, fmt.Errorf("convolution kernel %v, stride %v and dilation %v must be positive", kernel, stride, dilation)
+		}
+
+		if padding[i] < 0 {
+			return convolution{}, fmt.Errorf("convolution padding %v cannot be negative", padding)
+		}
+	}
+
+	return convolution{
+		inChannels:  inChannels,
+		outChannels: outChannels,
+		groups:      groups,
+		kernel:      kernel,
+		stride:      stride,
+		padBefore:   padding,
+		padAfter:    slices.Clone(padding),
+		dilation:    dilation,
+		paddingMode: paddingMode,
+	}, nil
+}
+
+func (conv *convolution) newParameters(context *NeuralContext, useBias bool) (*Tensor, *Tensor) {
+	fanIn := conv.inChannels / conv.groups * GetTotalElements(conv.kernel)
+	bound := 1 / math.Sqrt(float64(fanIn))
+	weights := NewTensorUniform(conv.outChannels*fanIn, -bound, bound, context.Random)
+	weights.Reshape(append([]int{conv.outChannels, conv.inChannels / conv.groups}, conv.kernel...)...)
+
+	if !useBias {
+		return weights, nil
+	}
+
+	return weights, NewTensorUniform(conv.outChannels, -bound, bound, context.Random)
+}
+
+func (conv *convolution) outputShape(spatial []int) ([]int, error) {
+	output := make([]int, len(spatial))
+	for i := range spatial {
+		span := conv.dilation[i]*(conv.kernel[i]-1) + 1
+		padded := spatial[i] + conv.padBefore[i] + conv.padAfter[i]
+		if padded < span {
+			return nil, fmt.Errorf("convolution kernel %v does not fit into padded input %v", conv.kernel, spatial)
+		}
+
+		if conv.paddingMode == ReflectPadding && (conv.padBefore[i] >= spatial[i] || conv.padAfter[i] >= spatial[i]) {
+			return nil, fmt.Errorf("reflect padding %v must be smaller than input %v", conv.padBefore, spatial)
+		}
+
+		output[i] = (padded-span)/conv.stride[i] + 1
+	}
+
+	return output, nil
+}
+
+func (conv *convolution) sourceCoordinate(position int, size int) int {
+	if position >= 0 && position < size {
+		return position
+	}
+
+	switch conv.paddingMode {
+	case ReflectPadding:
+		if position < 0 {
+			return -position
+		}
+
+		return 2*(size-1) - position
+	case ReplicatePadding:
+		return min(max(position, 0), size-1)
+	}
+
+	return -1
+}
+
+func unravelIndex(index int, shape []int, into []int) {
+	for i := len(shape) - 1; i >= 0; i-- {
+		into[i] = index % shape[i]
+		index /= shape[i]
+	}
+}
+
+// Maps every (kernel offset, output position) pair to the flat spatial input index it reads, or -1 for zero padding
+func (conv *convolution) sourceTable(spatial []int, output []int) []int {
+	kernelSize, outputSize := GetTotalElements(conv.kernel), GetTotalElements(output)
+	table := make([]int, kernelSize*outputSize)
+	offset, position := make([]int, len(spatial)), make([]int, len(spatial))
+
+	for k := 0; k < kernelSize; k++ {
+		unravelIndex(k, conv.kernel, offset)
+		for p := 0; p < outputSize; p++ {
+			unravelIndex(p, output, position)
+			source := 0
+			for i := range spatial {
+				coordinate := conv.sourceCoordinate(position[i]*conv.stride[i]+offset[i]*conv.dilation[i]-conv.padBefore[i], spatial[i])
+				if coordinate < 0 {
+					source = -1
+					break
+				}
+
+				source = source*spatial[i] + coordinate
+			}
+
+			table[k*outputSize+p] = source
+		}
+	}
+
+	return table
+}
+
+func (conv *convolution) forward(input *Tensor, weights *Tensor, bias *Tensor) (*Tensor, error) {
+	shape := input.Shape()
+	dims := len(conv.kernel)
+	batched := len(shape) == dims+2
+	if !batched && len(shape) != dims+1 {
+		return nil, fmt.Errorf("convolution expected input with %d or %d dimensions, got %v", dims+1, dims+2, shape)
+	}
+
+	if !batched {
+		shape = append([]int{1}, shape...)
+	}
+
+	batchSize, channels, spatial := shape[0], shape[1], shape[2:]
+	if channels != conv.inChannels {
+		return nil, fmt.Errorf("convolution expected %d input channels, got %d", conv.inChannels, channels)
+	}
+
+	output, err := conv.outputShape(spatial)
+	if err != nil {
+		return nil, err
+	}
+
+	table := conv.sourceTable(spatial, output)
+	kernelSize, outputSize, inputSize := GetTotalElements(conv.kernel), GetTotalElements(output), GetTotalElements(spatial)
+	groupIn, groupOut := conv.inChannels/conv.groups, conv.outChannels/conv.groups
+	rows := groupIn * kernelSize
+	x, w := input.Backing.Backing, weights.Backing.Backing
+
+	// im2col: every group of every sample gets a [rows, outputSize] matrix
+	columns := make([]float64, batchSize*conv.groups*rows*outputSize)
+	for b := 0; b < batchSize; b++ {
+		for g := 0; g < conv.groups; g++ {
+			for c := 0; c < groupIn; c++ {
+				channel := x[(b*channels+g*groupIn+c)*inputSize:]
+				for k := 0; k < kernelSize; k++ {
+					row := columns[((b*conv.groups+g)*rows+c*kernelSize+k)*outputSize:]
+					for p, source := range table[k*outputSize : (k+1)*outputSize] {
+						if source >= 0 {
+							row[p] = channel[source]
+						}
+					}
+				}
+			}
+		}
+	}
+
+	result := make([]float64, batchSize*conv.outChannels*outputSize)
+	for b := 0; b < batchSize; b++ {
+		for g := 0; g < conv.groups; g++ {
+			matrix := columns[(b*conv.groups+g)*rows*outputSize:]
+			for o := 0; o < groupOut; o++ {
+				filter := g*groupOut + o
+				out := result[(b*conv.outChannels+filter)*outputSize : (b*conv.outChannels+filter+1)*outputSize]
+				if bias != nil {
+					Fill(out, bias.Backing.Backing[filter])
+				}
+
+				for r, weight := range w[filter*rows : (filter+1)*rows] {
+					for p, value := range matrix[r*outputSize : (r+1)*outputSize] {
+						out[p] += weight * value
+					}
+				}
+			}
+		}
+	}
+
+	children := []*Tensor{input, weights}
+	if bias != nil {
+		children = append(children, bias)
+	}
+
+	outputDims := append([]int{conv.outChannels}, output...)
+	if batched {
+		outputDims = append([]int{batchSize}, outputDims...)
+	}
+
+	return &Tensor{
+		Backing:   NewNArray(result).Reshape(outputDims...),
+		Gradients: make([]float64, len(result)),
+		Children:  children,
+		backward: func(parent *Tensor) {
+			gradients := make([]float64, rows*outputSize)
+			for b := 0; b < batchSize; b++ {
+				for g := 0; g < conv.groups; g++ {
+					Fill(gradients, 0)
+					matrix := columns[(b*conv.groups+g)*rows*outputSize:]
+					for o := 0; o < groupOut; o++ {
+						filter := g*groupOut + o
+						upstream := parent.Gradients[(b*conv.outChannels+filter)*outputSize : (b*conv.outChannels+filter+1)*outputSize]
+						if bias != nil {
+							for _, gradient := range upstream {
+								bias.Gradients[filter] += gradient
+							}
+						}
+
+						for r := 0; r < rows; r++ {
+							weight := w[filter*rows+r]
+							sum := 0.0
+							row := matrix[r*outputSize : (r+1)*outputSize]
+							columnGradients := gradients[r*outputSize : (r+1)*outputSize]
+							for p, gradient := range upstream {
+								sum += gradient * row[p]
+								columnGradients[p] += gradient * weight
+							}
+
+							weights.Gradients[filter*rows+r] += sum
+						}
+					}
+
+					// col2im: scatter the column gradients back onto the input positions they were read from
+					for c := 0; c < groupIn; c++ {
+						channel := input.Gradients[(b*channels+g*groupIn+c)*inputSize:]
+						for k := 0; k < kernelSize; k++ {
+							columnGradients := gradients[(c*kernelSize+k)*outputSize:]
+							for p, source := range table[k*outputSize : (k+1)*outputSize] {
+								if source >= 0 {
+									channel[source] += columnGradients[p]
+								}
+							}
+						}
+					}
+				}
+			}
+		},
+	}, nil
+}
+
+type convLayer struct {
+	Weights *Tensor
+	Bias    *Tensor
+	conv    convolution
+	context *NeuralContext
+}
+
+func newConvLayer(context *NeuralContext, conv convolution, useBias bool) convLayer {
+	layer := convLayer{
+		conv:    conv,
+		context: context,
+	}
+
+	layer.Weights, layer.Bias = conv.newParameters(context, useBias)
+	return layer
+}
+
+func (layer *convLayer) UsesBias() bool {
+	return layer.Bias != nil
+}
+
+func (layer *convLayer) Zerograd() {
+	layer.Weights.Zerograd()
+
+	if layer.UsesBias() {
+		layer.Bias.Zerograd()
+	}
+}
+
+func (layer *convLayer) UpdateParameters(updateCallback UpdateTensorFunction) {
+	updateCallback(layer.context, layer.Weights)
+
+	if layer.UsesBias() {
+		updateCallback(layer.context, layer.Bias)
+	}
+}
+
+func (layer *convLayer) SetTraining(training bool) {
+}
+
+func (layer *convLayer) Execute(tensor *Tensor) (*Tensor, error) {
+	return layer.conv.forward(tensor, layer.Weights, layer.Bias)
+}
+
+type Conv1DLayer struct {
+	convLayer
+}
+
+func NewConv1DLayer(context *NeuralContext, inChannels int, outChannels int, kernelSize int, stride int, padding int, dilation int, groups int, useBias bool, paddingMode PaddingMode) (*Conv1DLayer, error) {
+	conv, err := newConvolution(inChannels, outChannels, []int{kernelSize}, []int{stride}, []int{padding}, []int{dilation}, groups, paddingMode)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Conv1DLayer{newConvLayer(context, conv, useBias)}, nil
+}
+
+func NewCausalConv1DLayer(context *NeuralContext, inChannels int, outChannels int, kernelSize int, stride int, dilation int, groups int, useBias bool) (*Conv1DLayer, error) {
+	conv, err := newConvolution(inChannels, outChannels, []int{kernelSize}, []int{stride}, []int{dilation * (kernelSize - 1)}, []int{dilation}, groups, ZeroPadding)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the past is padded so output step t never sees inputs after t
+	conv.padAfter[0] = 0
+
+	return &Conv1DLayer{newConvLayer(context, conv, useBias)}, nil
+}
+
+type Conv2DLayer struct {
+	convLayer
+}
+
+func NewConv2DLayer(context *NeuralContext, inChannels int, outChannels int, kernelSize int, stride int, padding int, dilation int, groups int, useBias bool, paddingMode PaddingMode) (*Conv2DLayer, error) {
+	conv, err := newConvolution(inChannels, outChannels, repeatInt(kernelSize, 2), repeatInt(stride, 2), repeatInt(padding, 2), repeatInt(dilation, 2), groups, paddingMode)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Conv2DLayer{newConvLayer(context, conv, useBias)}, nil
+}
+
+type Conv3DLayer struct {
+	convLayer
+}
+
+func NewConv3DLayer(context *NeuralContext, inChannels int, outChannels int, kernelSize int, stride int, padding int, dilation int, groups int, useBias bool, paddingMode PaddingMode) (*Conv3DLayer, error) {
+	conv, err := newConvolution(inChannels, outChannels, repeatInt(kernelSize, 3), repeatInt(stride, 3), repeatInt(padding, 3), repeatInt(dilation, 3), groups, paddingMode)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Conv3DLayer{newConvLayer(context, conv, useBias)}, nil
+}
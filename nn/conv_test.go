@@ -0,0 +1,99 @@
+package nn
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestConv2DGradients(t *testing.T) {
+	cases := []struct {
+		name                              string
+		stride, padding, dilation, groups int
+		mode                              PaddingMode
+	}{
+		{"plain", 1, 0, 1, 1, ZeroPadding},
+		{"strided zero padding", 2, 1, 1, 1, ZeroPadding},
+		{"dilated grouped", 1, 1, 2, 2, ZeroPadding},
+		{"reflect", 1, 1, 1, 1, ReflectPadding},
+		{"replicate", 2, 2, 1, 2, ReplicatePadding},
+	}
+
+	for _, c := range cases {
+		context := NewNeuralContext(0)
+		layer, err := NewConv2DLayer(context, 2, 4, 3, c.stride, c.padding, c.dilation, c.groups, true, c.mode)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		input := randomTensor(rand.New(rand.NewSource(1)), 2, 2, 5, 5)
+		checkGradients(t, c.name, []*Tensor{input, layer.Weights, layer.Bias}, func() (*Tensor, error) {
+			return layer.Execute(input)
+		})
+	}
+}
+
+func TestConv2DRejectsInvalidArguments(t *testing.T) {
+	context := NewNeuralContext(0)
+	cases := map[string]func() (*Conv2DLayer, error){
+		"zero kernel":      func() (*Conv2DLayer, error) { return NewConv2DLayer(context, 1, 1, 0, 1, 0, 1, 1, true, ZeroPadding) },
+		"zero stride":      func() (*Conv2DLayer, error) { return NewConv2DLayer(context, 1, 1, 3, 0, 0, 1, 1, true, ZeroPadding) },
+		"zero dilation":    func() (*Conv2DLayer, error) { return NewConv2DLayer(context, 1, 1, 3, 1, 0, 0, 1, true, ZeroPadding) },
+		"negative padding": func() (*Conv2DLayer, error) { return NewConv2DLayer(context, 1, 1, 3, 1, -1, 1, 1, true, ZeroPadding) },
+		"zero groups":      func() (*Conv2DLayer, error) { return NewConv2DLayer(context, 2, 2, 3, 1, 0, 1, 0, true, ZeroPadding) },
+		"uneven groups":    func() (*Conv2DLayer, error) { return NewConv2DLayer(context, 3, 2, 3, 1, 0, 1, 2, true, ZeroPadding) },
+		"zero channels":    func() (*Conv2DLayer, error) { return NewConv2DLayer(context, 0, 2, 3, 1, 0, 1, 1, true, ZeroPadding) },
+	}
+
+	for name, build := range cases {
+		if _, err := build(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestConv1DAndConv3DGradients(t *testing.T) {
+	context := NewNeuralContext(0)
+	conv1d, err := NewConv1DLayer(context, 2, 4, 3, 2, 1, 1, 2, true, ReflectPadding)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	causal, err := NewCausalConv1DLayer(context, 2, 2, 3, 1, 2, 1, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	conv3d, err := NewConv3DLayer(context, 1, 2, 2, 1, 1, 1, 1, true, ZeroPadding)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	random := rand.New(rand.NewSource(1))
+	sequence, volume := randomTensor(random, 2, 2, 7), randomTensor(random, 1, 3, 3, 3)
+	checkGradients(t, "conv1d", []*Tensor{sequence, conv1d.Weights, conv1d.Bias}, func() (*Tensor, error) {
+		return conv1d.Execute(sequence)
+	})
+
+	checkGradients(t, "causal conv1d", []*Tensor{sequence, causal.Weights}, func() (*Tensor, error) {
+		return causal.Execute(sequence)
+	})
+
+	checkGradients(t, "conv3d", []*Tensor{volume, conv3d.Weights, conv3d.Bias}, func() (*Tensor, error) {
+		return conv3d.Execute(volume)
+	})
+}
+
+func TestConv1DAndConv3DRejectInvalidArguments(t *testing.T) {
+	context := NewNeuralContext(0)
+	if _, err := NewConv1DLayer(context, 1, 1, 3, 0, 0, 1, 1, true, ZeroPadding); err == nil {
+		t.Error("conv1d: expected an error for stride 0")
+	}
+
+	if _, err := NewCausalConv1DLayer(context, 1, 1, 0, 1, 1, 1, true); err == nil {
+		t.Error("causal conv1d: expected an error for kernel 0")
+	}
+
+	if _, err := NewConv3DLayer(context, 1, 1, 2, 1, 0, 0, 1, true, ZeroPadding); err == nil {
+		t.Error("conv3d: expected an error for dilation 0")
+	}
+}
@@ -0,0 +1,176 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+)
+
+// A transposed convolution is the input gradient of a regular convolution, so it reuses the
+// convolution source table with the large output playing the role of the convolution input
+func (conv *convolution) transposedForward(input *Tensor, weights *Tensor, bias *Tensor, outputPadding []int) (*Tensor, error) {
+	shape := input.Shape()
+	dims := len(conv.kernel)
+	batched := len(shape) == dims+2
+	if !batched && len(shape) != dims+1 {
+		return nil, fmt.Errorf("transposed convolution expected input with %d or %d dimensions, got %v", dims+1, dims+2, shape)
+	}
+
+	if !batched {
+		shape = append([]int{1}, shape...)
+	}
+
+	batchSize, channels, spatial := shape[0], shape[1], shape[2:]
+	if channels != conv.outChannels {
+		return nil, fmt.Errorf("transposed convolution expected %d input channels, got %d", conv.outChannels, channels)
+	}
+
+	output := make([]int, dims)
+	for i := range output {
+		output[i] = (spatial[i]-1)*conv.stride[i] - conv.padBefore[i] - conv.padAfter[i] + conv.dilation[i]*(conv.kernel[i]-1) + outputPadding[i] + 1
+		if output[i] <= 0 {
+			return nil, fmt.Errorf("transposed convolution of input %v produces an empty output", spatial)
+		}
+	}
+
+	table := conv.sourceTable(output, spatial)
+	kernelSize, inputSize, outputSize := GetTotalElements(conv.kernel), GetTotalElements(spatial), GetTotalElements(output)
+	groupOut, groupIn := conv.inChannels/conv.groups, conv.outChannels/conv.groups
+	rows := groupOut * kernelSize
+	x, w := input.Backing.Backing, weights.Backing.Backing
+
+	result := make([]float64, batchSize*conv.inChannels*outputSize)
+	for b := 0; b < batchSize; b++ {
+		for g := 0; g < conv.groups; g++ {
+			for c := 0; c < groupOut; c++ {
+				out := result[(b*conv.inChannels+g*groupOut+c)*outputSize : (b*conv.inChannels+g*groupOut+c+1)*outputSize]
+				if bias != nil {
+					Fill(out, bias.Backing.Backing[g*groupOut+c])
+				}
+
+				for k := 0; k < kernelSize; k++ {
+					targets := table[k*inputSize : (k+1)*inputSize]
+					for i := 0; i < groupIn; i++ {
+						channel := g*groupIn + i
+						weight := w[channel*rows+c*kernelSize+k]
+						values := x[(b*channels+channel)*inputSize : (b*channels+channel+1)*inputSize]
+						for p, target := range targets {
+							if target >= 0 {
+								out[target] += weight * values[p]
+							}
+						}
+					}
+				}
+			}
+		}
+	}
+
+	children := []*Tensor{input, weights}
+	if bias != nil {
+		children = append(children, bias)
+	}
+
+	outputDims := append([]int{conv.inChannels}, output...)
+	if batched {
+		outputDims = append([]int{batchSize}, outputDims...)
+	}
+
+	return &Tensor{
+		Backing:   NewNArray(result).Reshape(outputDims...),
+		Gradients: make([]float64, len(result)),
+		Children:  children,
+		backward: func(parent *Tensor) {
+			for b := 0; b < batchSize; b++ {
+				for g := 0; g < conv.groups; g++ {
+					for c := 0; c < groupOut; c++ {
+						upstream := parent.Gradients[(b*conv.inChannels+g*groupOut+c)*outputSize : (b*conv.inChannels+g*groupOut+c+1)*outputSize]
+						if bias != nil {
+							for _, gradient := range upstream {
+								bias.Gradients[g*groupOut+c] += gradient
+							}
+						}
+
+						for k := 0; k < kernelSize; k++ {
+							targets := table[k*inputSize : (k+1)*inputSize]
+							for i := 0; i < groupIn; i++ {
+								channel := g*groupIn + i
+								index := channel*rows + c*kernelSize + k
+								values := x[(b*channels+channel)*inputSize : (b*channels+channel+1)*inputSize]
+								inputGradients := input.Gradients[(b*channels+channel)*inputSize : (b*channels+channel+1)*inputSize]
+								sum := 0.0
+								for p, target := range targets {
+									if target >= 0 {
+										sum += values[p] * upstream[target]
+										inputGradients[p] += w[index] * upstream[target]
+									}
+								}
+
+								weights.Gradients[index] += sum
+							}
+						}
+					}
+				}
+			}
+		},
+	}, nil
+}
+
+type ConvTranspose2DLayer struct {
+	Weights       *Tensor
+	Bias          *Tensor
+	conv          convolution
+	outputPadding []int
+	context       *NeuralContext
+}
+
+func NewConvTranspose2DLayer(context *NeuralContext, inChannels int, outChannels int, kernelSize int, stride int, padding int, outputPadding int, dilation int, groups int, useBias bool) (*ConvTranspose2DLayer, error) {
+	if outputPadding < 0 || (outputPadding >= stride && outputPadding >= dilation) {
+		return nil, fmt.Errorf("output padding %d must be non-negative and smaller than either stride %d or dilation %d", outputPadding, stride, dilation)
+	}
+
+	// Weights are laid out [inChannels, outChannels/groups, kh, kw], the regular convolution layout with channels swapped
+	conv, err := newConvolution(outChannels, inChannels, repeatInt(kernelSize, 2), repeatInt(stride, 2), repeatInt(padding, 2), repeatInt(dilation, 2), groups, ZeroPadding)
+	if err != nil {
+		return nil, err
+	}
+
+	layer := &ConvTranspose2DLayer{
+		conv:          conv,
+		outputPadding: repeatInt(outputPadding, 2),
+		context:       context,
+	}
+
+	layer.Weights, _ = conv.newParameters(context, false)
+	if useBias {
+		bound := 1 / math.Sqrt(float64(outChannels/groups*kernelSize*kernelSize))
+		layer.Bias = NewTensorUniform(outChannels, -bound, bound, context.Random)
+	}
+
+	return layer, nil
+}
+
+func (layer *ConvTranspose2DLayer) UsesBias() bool {
+	return layer.Bias != nil
+}
+
+func (layer *ConvTranspose2DLayer) Zerograd() {
+	layer.Weights.Zerograd()
+
+	if layer.UsesBias() {
+		layer.Bias.Zerograd()
+	}
+}
+
+func (layer *ConvTranspose2DLayer) UpdateParameters(updateCallback UpdateTensorFunction) {
+	updateCallback(layer.context, layer.Weights)
+
+	if layer.UsesBias() {
+		updateCallback(layer.context, layer.Bias)
+	}
+}
+
+func (layer *ConvTranspose2DLayer) SetTraining(training bool) {
+}
+
+func (layer *ConvTranspose2DLayer) Execute(tensor *Tensor) (*Tensor, error) {
+	return layer.conv.transposedForward(tensor, layer.Weights, layer.Bias, layer.outputPadding)
+}
@@ -0,0 +1,44 @@
+package nn
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestConvTranspose2DGradients(t *testing.T) {
+	cases := []struct {
+		name                                             string
+		stride, padding, outputPadding, dilation, groups int
+	}{
+		{"plain", 1, 0, 0, 1, 1},
+		{"strided", 2, 1, 1, 1, 1},
+		{"dilated grouped", 1, 1, 0, 2, 2},
+	}
+
+	for _, c := range cases {
+		layer, err := NewConvTranspose2DLayer(NewNeuralContext(0), 2, 4, 3, c.stride, c.padding, c.outputPadding, c.dilation, c.groups, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		input := randomTensor(rand.New(rand.NewSource(1)), 2, 2, 3, 3)
+		checkGradients(t, c.name, []*Tensor{input, layer.Weights, layer.Bias}, func() (*Tensor, error) {
+			return layer.Execute(input)
+		})
+	}
+}
+
+func TestConvTranspose2DRejectsInvalidArguments(t *testing.T) {
+	context := NewNeuralContext(0)
+	if _, err := NewConvTranspose2DLayer(context, 1, 1, 3, 0, 0, 0, 1, 1, true); err == nil {
+		t.Error("expected an error for stride 0")
+	}
+
+	if _, err := NewConvTranspose2DLayer(context, 1, 1, 3, 2, 0, 2, 1, 1, true); err == nil {
+		t.Error("expected an error for output padding as large as the stride")
+	}
+
+	if _, err := NewConvTranspose2DLayer(context, 2, 3, 3, 1, 0, 0, 1, 2, true); err == nil {
+		t.Error("expected an error for channels that do not divide into groups")
+	}
+}
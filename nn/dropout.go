@@ -0,0 +1,141 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+type DropoutMode int
+
+const (
+	ElementDropout DropoutMode = iota
+	ChannelDropout
+	AlphaDropout
+	PathDropout
+)
+
+// Negative saturation value of SELU, dropped units are set to it so the self-normalizing statistics survive
+const seluSaturation = -1.7580993408473766
+
+type DropoutLayer struct {
+	Probability float64
+	Mode        DropoutMode
+	training    bool
+	context     *NeuralContext
+}
+
+func newDropoutLayer(context *NeuralContext, probability float64, mode DropoutMode) *DropoutLayer {
+	if probability < 0 || probability > 1 || (mode == AlphaDropout && probability == 1) {
+		panic(fmt.Sprintf("dropout probability %v is out of range", probability))
+	}
+
+	return &DropoutLayer{
+		Probability: probability,
+		Mode:        mode,
+		training:    true,
+		context:     context,
+	}
+}
+
+func NewDropoutLayer(context *NeuralContext, probability float64) *DropoutLayer {
+	return newDropoutLayer(context, probability, ElementDropout)
+}
+
+// Zeroes whole [H,W] feature maps of [B,C,H,W] or [C,H,W] input
+func NewDropout2DLayer(context *NeuralContext, probability float64) *DropoutLayer {
+	return newDropoutLayer(context, probability, ChannelDropout)
+}
+
+func NewAlphaDropoutLayer(context *NeuralContext, probability float64) *DropoutLayer {
+	return newDropoutLayer(context, probability, AlphaDropout)
+}
+
+// Stochastic depth, drops the entire sample along the leading batch dimension, meant for residual branches
+func NewDropPathLayer(context *NeuralContext, probability float64) *DropoutLayer {
+	return newDropoutLayer(context, probability, PathDropout)
+}
+
+func (layer *DropoutLayer) IsTraining() bool {
+	return layer.training
+}
+
+func (layer *DropoutLayer) Zerograd() {
+}
+
+func (layer *DropoutLayer) UpdateParameters(updateCallback UpdateTensorFunction) {
+}
+
+func (layer *DropoutLayer) SetTraining(training bool) {
+	layer.training = training
+}
+
+// Number of consecutive elements sharing a single mask entry
+func (layer *DropoutLayer) unitSize(shape []int) (int, error) {
+	switch layer.Mode {
+	case ChannelDropout:
+		if len(shape) != 3 && len(shape) != 4 {
+			return 0, fmt.Errorf("dropout 2d expected input of shape [B,C,H,W] or [C,H,W], got %v", shape)
+		}
+
+		return shape[len(shape)-2] * shape[len(shape)-1], nil
+	case PathDropout:
+		if len(shape) < 2 {
+			return GetTotalElements(shape), nil
+		}
+
+		return GetTotalElements(shape[1:]), nil
+	default:
+		return 1, nil
+	}
+}
+
+func (layer *DropoutLayer) Execute(tensor *Tensor) (*Tensor, error) {
+	if !layer.training || layer.Probability == 0 {
+		return tensor, nil
+	}
+
+	shape := slices.Clone(tensor.Shape())
+	unit, err := layer.unitSize(shape)
+	if err != nil {
+		return nil, err
+	}
+
+	keep := 1 - layer.Probability
+	values := tensor.Backing.Backing
+	scales := make([]float64, len(values))
+	offsets := make([]float64, len(values))
+	// Alpha dropout keeps mean and variance by an affine correction instead of the inverted 1/keep scaling
+	alphaScale := 1 / math.Sqrt(keep*(1+layer.Probability*seluSaturation*seluSaturation))
+	alphaShift := -alphaScale * seluSaturation * layer.Probability
+
+	for start := 0; start < len(values); start += unit {
+		kept := layer.context.Random.Float64() < keep
+		for i := start; i < start+unit && i < len(values); i++ {
+			switch {
+			case layer.Mode == AlphaDropout && kept:
+				scales[i], offsets[i] = alphaScale, alphaShift
+			case layer.Mode == AlphaDropout:
+				offsets[i] = alphaScale*seluSaturation + alphaShift
+			case kept:
+				scales[i] = 1 / keep
+			}
+		}
+	}
+
+	result := make([]float64, len(values))
+	for i := range result {
+		result[i] = values[i]*scales[i] + offsets[i]
+	}
+
+	return &Tensor{
+		Backing:   NewNArray(result).Reshape(shape...),
+		Gradients: make([]float64, len(result)),
+		Children:  []*Tensor{tensor},
+		backward: func(parent *Tensor) {
+			for i := range tensor.Gradients {
+				tensor.Gradients[i] += parent.Gradients[i] * scales[i]
+			}
+		},
+	}, nil
+}
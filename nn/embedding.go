@@ -0,0 +1,331 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+const NoPadding = -1
+
+type EmbeddingLayer struct {
+	Weights    *Tensor
+	PaddingIdx int
+	MaxNorm    float64
+	NormType   float64
+	Sparse     bool
+	Frozen     bool
+	touched    map[int]bool
+	rows       map[int]*Tensor
+	context    *NeuralContext
+}
+
+func NewEmbeddingLayer(context *NeuralContext, vocabularySize int, dimension int, paddingIdx int) *EmbeddingLayer {
+	if vocabularySize <= 0 || dimension <= 0 {
+		panic(fmt.Sprintf("embedding size [%d,%d] must be positive", vocabularySize, dimension))
+	}
+
+	if paddingIdx != NoPadding && (paddingIdx < 0 || paddingIdx >= vocabularySize) {
+		panic(fmt.Sprintf("padding index %d is outside of vocabulary %d", paddingIdx, vocabularySize))
+	}
+
+	weights := NewTensorEmpty(vocabularySize * dimension)
+	for i := range weights.Backing.Backing {
+		weights.Backing.Backing[i] = context.Random.NormFloat64()
+	}
+
+	layer := newEmbeddingLayer(context, weights.Reshape(vocabularySize, dimension), paddingIdx)
+	if paddingIdx != NoPadding {
+		Fill(layer.Vector(paddingIdx), 0)
+	}
+
+	return layer
+}
+
+// Copies the given [vocab][dim] vectors, the padding row keeps whatever the pretrained table holds
+func NewPretrainedEmbeddingLayer(context *NeuralContext, vectors [][]float64, paddingIdx int, frozen bool) (*EmbeddingLayer, error) {
+	if len(vectors) == 0 || len(vectors[0]) == 0 {
+		return nil, fmt.Errorf("pretrained embedding requires at least one non empty vector")
+	}
+
+	if paddingIdx != NoPadding && (paddingIdx < 0 || paddingIdx >= len(vectors)) {
+		return nil, fmt.Errorf("padding index %d is outside of vocabulary %d", paddingIdx, len(vectors))
+	}
+
+	dimension := len(vectors[0])
+	values := make([]float64, 0, len(vectors)*dimension)
+	for i, vector := range vectors {
+		if len(vector) != dimension {
+			return nil, fmt.Errorf("pretrained vector %d has dimension %d, expected %d", i, len(vector), dimension)
+		}
+
+		values = append(values, vector...)
+	}
+
+	layer := newEmbeddingLayer(context, NewTensorFromArray(values).Reshape(len(vectors), dimension), paddingIdx)
+	layer.Frozen = frozen
+
+	return layer, nil
+}
+
+func newEmbeddingLayer(context *NeuralContext, weights *Tensor, paddingIdx int) *EmbeddingLayer {
+	return &EmbeddingLayer{
+		Weights:    weights,
+		PaddingIdx: paddingIdx,
+		NormType:   2,
+		touched:    make(map[int]bool),
+		rows:       make(map[int]*Tensor),
+		context:    context,
+	}
+}
+
+func (layer *EmbeddingLayer) VocabularySize() int {
+	return layer.Weights.Shape()[0]
+}
+
+func (layer *EmbeddingLayer) Dimension() int {
+	return layer.Weights.Shape()[1]
+}
+
+func (layer *EmbeddingLayer) Vector(id int) []float64 {
+	dimension := layer.Dimension()
+	return layer.Weights.Backing.Backing[id*dimension : (id+1)*dimension]
+}
+
+// Views of single weight rows that share memory with Weights, cached so callers see stable tensors per row
+func (layer *EmbeddingLayer) row(id int) *Tensor {
+	if tensor, ok := layer.rows[id]; ok {
+		return tensor
+	}
+
+	dimension := layer.Dimension()
+	tensor := &Tensor{
+		Backing:   NewNArray(layer.Vector(id)),
+		Gradients: layer.Weights.Gradients[id*dimension : (id+1)*dimension],
+		Children:  make([]*Tensor, 0),
+	}
+
+	layer.rows[id] = tensor
+	return tensor
+}
+
+// Rows touched since the last Zerograd in ascending order
+func (layer *EmbeddingLayer) TouchedRows() []int {
+	ids := make([]int, 0, len(layer.touched))
+	for id := range layer.touched {
+		ids = append(ids, id)
+	}
+
+	slices.Sort(ids)
+	return ids
+}
+
+func (layer *EmbeddingLayer) Zerograd() {
+	if !layer.Sparse {
+		layer.Weights.Zerograd()
+		clear(layer.touched)
+		return
+	}
+
+	for id := range layer.touched {
+		Fill(layer.row(id).Gradients, 0)
+	}
+
+	clear(layer.touched)
+}
+
+// Sparse layers only hand the looked up rows to the callback so an update costs O(rows used) instead of O(vocab)
+func (layer *EmbeddingLayer) UpdateParameters(updateCallback UpdateTensorFunction) {
+	if layer.Frozen {
+		return
+	}
+
+	if !layer.Sparse {
+		updateCallback(layer.context, layer.Weights)
+		return
+	}
+
+	for _, id := range layer.TouchedRows() {
+		updateCallback(layer.context, layer.row(id))
+	}
+}
+
+func (layer *EmbeddingLayer) SetTraining(training bool) {
+}
+
+func (layer *EmbeddingLayer) ids(tensor *Tensor) ([]int, error) {
+	ids := make([]int, len(tensor.Backing.Backing))
+	for i, value := range tensor.Backing.Backing {
+		id := int(value)
+		if float64(id) != value || id < 0 || id >= layer.VocabularySize() {
+			return nil, fmt.Errorf("embedding index %v is not in range [0,%d)", value, layer.VocabularySize())
+		}
+
+		ids[i] = id
+	}
+
+	return ids, nil
+}
+
+// Rescales looked up rows in place whose norm exceeds MaxNorm, like the lookup itself this is not differentiated
+func (layer *EmbeddingLayer) renormalize(ids []int) {
+	if layer.MaxNorm <= 0 {
+		return
+	}
+
+	for _, id := range ids {
+		vector := layer.Vector(id)
+		norm := 0.0
+		for _, value := range vector {
+			norm += math.Pow(math.Abs(value), layer.NormType)
+		}
+
+		norm = math.Pow(norm, 1/layer.NormType)
+		if norm > layer.MaxNorm {
+			scale := layer.MaxNorm / (norm + 1e-7)
+			for i := range vector {
+				vector[i] *= scale
+			}
+		}
+	}
+}
+
+// Every output row is the weighted sum of its bag, a plain lookup is a bag of one id with weight 1.
+// Padding ids never receive gradient and pooled bags leave them out entirely
+func (layer *EmbeddingLayer) gather(bags [][]int, pooled bool, mean bool, outputDims []int) *Tensor {
+	dimension := layer.Dimension()
+	factors := make([]float64, len(bags))
+	result := make([]float64, len(bags)*dimension)
+	for b, bag := range bags {
+		layer.renormalize(bag)
+		count := 0
+		for _, id := range bag {
+			if !pooled || id != layer.PaddingIdx {
+				count++
+			}
+		}
+
+		if count == 0 {
+			continue
+		}
+
+		factors[b] = 1
+		if mean {
+			factors[b] = 1 / float64(count)
+		}
+
+		out := result[b*dimension : (b+1)*dimension]
+		for _, id := range bag {
+			if pooled && id == layer.PaddingIdx {
+				continue
+			}
+
+			for i, value := range layer.Vector(id) {
+				out[i] += factors[b] * value
+			}
+		}
+	}
+
+	weights := layer.Weights
+	return &Tensor{
+		Backing:   NewNArray(result).Reshape(outputDims...),
+		Gradients: make([]float64, len(result)),
+		Children:  []*Tensor{weights},
+		backward: func(parent *Tensor) {
+			for b, bag := range bags {
+				upstream := parent.Gradients[b*dimension : (b+1)*dimension]
+				for _, id := range bag {
+					if id == layer.PaddingIdx {
+						continue
+					}
+
+					layer.touched[id] = true
+					gradients := weights.Gradients[id*dimension : (id+1)*dimension]
+					for i := range gradients {
+						gradients[i] += factors[b] * upstream[i]
+					}
+				}
+			}
+		},
+	}
+}
+
+func (layer *EmbeddingLayer) Execute(tensor *Tensor) (*Tensor, error) {
+	ids, err := layer.ids(tensor)
+	if err != nil {
+		return nil, err
+	}
+
+	bags := make([][]int, len(ids))
+	for i, id := range ids {
+		bags[i] = []int{id}
+	}
+
+	return layer.gather(bags, false, false, append(slices.Clone(tensor.Shape()), layer.Dimension())), nil
+}
+
+type BagMode int
+
+const (
+	SumBag BagMode = iota
+	MeanBag
+)
+
+type EmbeddingBagLayer struct {
+	EmbeddingLayer
+	Mode BagMode
+}
+
+func NewEmbeddingBagLayer(context *NeuralContext, vocabularySize int, dimension int, mode BagMode, paddingIdx int) *EmbeddingBagLayer {
+	return &EmbeddingBagLayer{
+		EmbeddingLayer: *NewEmbeddingLayer(context, vocabularySize, dimension, paddingIdx),
+		Mode:           mode,
+	}
+}
+
+// Pools [L] input into one [dim] vector or [B,L] input into [B,dim], padding ids are left out of the pool
+func (layer *EmbeddingBagLayer) Execute(tensor *Tensor) (*Tensor, error) {
+	shape := tensor.Shape()
+	if len(shape) != 1 && len(shape) != 2 {
+		return nil, fmt.Errorf("embedding bag expected input of shape [L] or [B,L], got %v", shape)
+	}
+
+	if len(shape) == 2 && shape[1] == 0 {
+		return nil, fmt.Errorf("embedding bag cannot split input of shape %v into empty bags, use ExecuteWithOffsets", shape)
+	}
+
+	ids, err := layer.ids(tensor)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(shape) == 1 {
+		return layer.gather([][]int{ids}, true, layer.Mode == MeanBag, []int{layer.Dimension()}), nil
+	}
+
+	return layer.gather(slices.Collect(slices.Chunk(ids, shape[1])), true, layer.Mode == MeanBag, []int{shape[0], layer.Dimension()}), nil
+}
+
+// Bags of varying length packed into one flat id tensor, bag i spans offsets[i] up to the next offset
+func (layer *EmbeddingBagLayer) ExecuteWithOffsets(tensor *Tensor, offsets []int) (*Tensor, error) {
+	ids, err := layer.ids(tensor)
+	if err != nil {
+		return nil, err
+	}
+
+	bags := make([][]int, len(offsets))
+	for i, start := range offsets {
+		end := len(ids)
+		if i+1 < len(offsets) {
+			end = offsets[i+1]
+		}
+
+		if start < 0 || start > end || end > len(ids) {
+			return nil, fmt.Errorf("embedding bag offsets %v are not increasing within %d ids", offsets, len(ids))
+		}
+
+		bags[i] = ids[start:end]
+	}
+
+	return layer.gather(bags, true, layer.Mode == MeanBag, []int{len(bags), layer.Dimension()}), nil
+}
@@ -0,0 +1,57 @@
+package nn
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestEmbeddingGradients(t *testing.T) {
+	context := NewNeuralContext(0)
+	ids := NewTensorFromArray([]float64{1, 3, 1, 0, 4, 3}).Reshape(2, 3)
+	for _, sparse := range []bool{false, true} {
+		layer := NewEmbeddingLayer(context, 5, 3, NoPadding)
+		layer.Sparse = sparse
+		checkGradients(t, "embedding", []*Tensor{layer.Weights}, func() (*Tensor, error) {
+			return layer.Execute(ids)
+		})
+	}
+
+	for _, mode := range []BagMode{SumBag, MeanBag} {
+		bag := NewEmbeddingBagLayer(context, 5, 3, mode, 2)
+		checkGradients(t, "embedding bag", []*Tensor{bag.Weights}, func() (*Tensor, error) {
+			return bag.Execute(NewTensorFromArray([]float64{1, 2, 1, 0, 4, 3}).Reshape(2, 3))
+		})
+
+		checkGradients(t, "embedding bag offsets", []*Tensor{bag.Weights}, func() (*Tensor, error) {
+			return bag.ExecuteWithOffsets(NewTensorFromArray([]float64{1, 2, 1, 0, 4, 3}), []int{0, 1, 4})
+		})
+	}
+}
+
+func TestSparseEmbeddingUpdatesTouchedRows(t *testing.T) {
+	layer := NewEmbeddingLayer(NewNeuralContext(0), 6, 2, 0)
+	layer.Sparse = true
+	output, err := layer.Execute(NewTensorFromArray([]float64{4, 0, 1, 4}))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output.Sum().Backward()
+	if rows := layer.TouchedRows(); !slices.Equal(rows, []int{1, 4}) {
+		t.Fatalf("expected rows [1 4] to be touched, got %v", rows)
+	}
+
+	updated := make([][]float64, 0)
+	layer.UpdateParameters(func(context *NeuralContext, tensor *Tensor) {
+		updated = append(updated, slices.Clone(tensor.Gradients))
+	})
+
+	if !slices.EqualFunc(updated, [][]float64{{1, 1}, {2, 2}}, slices.Equal[[]float64]) {
+		t.Fatalf("expected the gradients of rows 1 and 4 only, got %v", updated)
+	}
+
+	layer.Zerograd()
+	if rows := layer.TouchedRows(); len(rows) != 0 || slices.ContainsFunc(layer.Weights.Gradients, func(value float64) bool { return value != 0 }) {
+		t.Fatalf("expected Zerograd to clear touched rows %v and their gradients", rows)
+	}
+}
@@ -14,6 +14,9 @@ func (layer *FlattenLayer) Zerograd() {
 func (layer *FlattenLayer) UpdateParameters(updateCallback UpdateTensorFunction) {
 }
 
+func (layer *FlattenLayer) SetTraining(training bool) {
+}
+
 func (layer *FlattenLayer) Execute(tensor *Tensor) (*Tensor, error) {
 	return tensor.Flatten(), nil
 }
@@ -0,0 +1,71 @@
+package nn
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func randomTensor(random *rand.Rand, dims ...int) *Tensor {
+	values := make([]float64, GetTotalElements(dims))
+	for i := range values {
+		values[i] = random.Float64()*2 - 1
+	}
+
+	return NewTensorFromArray(values).Reshape(dims...)
+}
+
+// Compares the analytic gradients of a random projection of forward() against central differences
+func checkGradients(t *testing.T, name string, parameters []*Tensor, forward func() (*Tensor, error)) {
+	t.Helper()
+	random := rand.New(rand.NewSource(42))
+	for _, parameter := range parameters {
+		parameter.Zerograd()
+	}
+
+	output, err := forward()
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+
+	projection := make([]float64, len(output.Backing.Backing))
+	for i := range projection {
+		projection[i] = random.Float64()*2 - 1
+	}
+
+	loss := func() float64 {
+		output, err := forward()
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+
+		sum := 0.0
+		for i, value := range output.Backing.Backing {
+			sum += value * projection[i]
+		}
+
+		return sum
+	}
+
+	projected, err := TensorMul(output, NewTensorFromArray(projection))
+	if err != nil {
+		t.Fatalf("%s: %v", name, err)
+	}
+
+	projected.Sum().Backward()
+	const step = 1e-6
+	for p, parameter := range parameters {
+		for i, value := range parameter.Backing.Backing {
+			parameter.Backing.Backing[i] = value + step
+			above := loss()
+			parameter.Backing.Backing[i] = value - step
+			below := loss()
+			parameter.Backing.Backing[i] = value
+
+			numeric := (above - below) / (2 * step)
+			if math.Abs(numeric-parameter.Gradients[i]) > 1e-4*math.Max(1, math.Abs(numeric)) {
+				t.Fatalf("%s: parameter %d element %d has gradient %v, finite differences give %v", name, p, i, parameter.Gradients[i], numeric)
+			}
+		}
+	}
+}
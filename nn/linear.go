@@ -3,6 +3,7 @@ package nn
 import (
 	"errors"
 	"fmt"
+	"slices"
 )
 
 type LinearNeuron struct {
@@ -70,14 +71,16 @@ func (neuron *LinearNeuron) Execute(tensor *Tensor) (*Tensor, error) {
 }
 
 type LinearLayer struct {
-	Neurons []*LinearNeuron
-	context *NeuralContext
+	Neurons    []*LinearNeuron
+	activation ActivationFunction
+	context    *NeuralContext
 }
 
 func NewLinearLayer(context *NeuralContext, inputs int, outputs int, useBias bool, activation ActivationFunction) *LinearLayer {
 	layer := &LinearLayer{
-		Neurons: make([]*LinearNeuron, outputs),
-		context: context,
+		Neurons:    make([]*LinearNeuron, outputs),
+		activation: activation,
+		context:    context,
 	}
 
 	for i := 0; i < outputs; i++ {
@@ -99,6 +102,9 @@ func (layer *LinearLayer) UpdateParameters(updateCallback UpdateTensorFunction)
 	}
 }
 
+func (layer *LinearLayer) SetTraining(training bool) {
+}
+
 func (layer *LinearLayer) Execute(tensor *Tensor) (*Tensor, error) {
 	results := make([]*Tensor, len(layer.Neurons))
 	var err error
@@ -115,3 +121,63 @@ func (layer *LinearLayer) Execute(tensor *Tensor) (*Tensor, error) {
 
 	return NewFromTensors(results), nil
 }
+
+// Reads [...,inputs] and returns [...,outputs] in one product over the stacked neuron weights, so the
+// neurons must share input size and bias use. The layer activation from NewLinearLayer is applied
+func (layer *LinearLayer) ExecuteBatched(tensor *Tensor) (*Tensor, error) {
+	if len(layer.Neurons) == 0 || layer.activation == nil {
+		return nil, errors.New("batched linear layer needs neurons and an activation from NewLinearLayer")
+	}
+
+	first := layer.Neurons[0]
+	weights := make([]*Tensor, len(layer.Neurons))
+	biases := make([]*Tensor, 0, len(layer.Neurons))
+	for i, neuron := range layer.Neurons {
+		if len(neuron.Weights.Backing.Backing) != len(first.Weights.Backing.Backing) || neuron.UsesBias() != first.UsesBias() {
+			return nil, fmt.Errorf("linear neuron %d differs from neuron 0 in input size or bias use", i)
+		}
+
+		weights[i] = neuron.Weights
+		if neuron.UsesBias() {
+			biases = append(biases, neuron.Bias)
+		}
+	}
+
+	stacked, err := Stack(weights)
+	if err != nil {
+		return nil, err
+	}
+
+	var bias *Tensor
+	if len(biases) > 0 {
+		bias, err = Stack(biases)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result, err := Linear(tensor, stacked, bias)
+	if err != nil {
+		return nil, err
+	}
+
+	activated, err := layer.activation(result)
+	if err != nil {
+		return nil, err
+	}
+
+	return activated.Reshape(slices.Clone(result.Shape())...), nil
+}
+
+// Linear layer for [...,inputs] input, Execute goes through ExecuteBatched
+type BatchedLinearLayer struct {
+	*LinearLayer
+}
+
+func NewBatchedLinearLayer(context *NeuralContext, inputs int, outputs int, useBias bool, activation ActivationFunction) *BatchedLinearLayer {
+	return &BatchedLinearLayer{NewLinearLayer(context, inputs, outputs, useBias, activation)}
+}
+
+func (layer *BatchedLinearLayer) Execute(tensor *Tensor) (*Tensor, error) {
+	return layer.ExecuteBatched(tensor)
+}
@@ -0,0 +1,65 @@
+package nn
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+)
+
+func TestLinearLayerExecuteBatchedMatchesNeurons(t *testing.T) {
+	layer := NewLinearLayer(NewNeuralContext(0), 3, 4, true, TanhActivation)
+	input := randomTensor(rand.New(rand.NewSource(1)), 2, 5, 3)
+	batched, err := layer.ExecuteBatched(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if shape := batched.Shape(); len(shape) != 3 || shape[0] != 2 || shape[1] != 5 || shape[2] != 4 {
+		t.Fatalf("expected batched output of shape [2 5 4], got %v", shape)
+	}
+
+	for row := 0; row < 10; row++ {
+		output, err := layer.Execute(NewTensorFromArray(input.Backing.Backing[row*3 : row*3+3]))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		for i, value := range output.Backing.Backing {
+			if math.Abs(value-batched.Backing.Backing[row*4+i]) > 1e-12 {
+				t.Fatalf("row %d output %d is %v per neuron but %v batched", row, i, value, batched.Backing.Backing[row*4+i])
+			}
+		}
+	}
+
+}
+
+func TestLinearLayerExecuteBatchedGradients(t *testing.T) {
+	layer := NewLinearLayer(NewNeuralContext(0), 3, 4, true, NoneActivation)
+	input := randomTensor(rand.New(rand.NewSource(1)), 2, 5, 3)
+	parameters := []*Tensor{input}
+	for _, neuron := range layer.Neurons {
+		parameters = append(parameters, neuron.Weights, neuron.Bias)
+	}
+
+	checkGradients(t, "batched linear", parameters, func() (*Tensor, error) {
+		return layer.ExecuteBatched(input)
+	})
+}
+
+func TestLinearLayerExecuteBatchedRejectsMixedNeurons(t *testing.T) {
+	context := NewNeuralContext(0)
+	layer := NewLinearLayer(context, 3, 2, true, NoneActivation)
+	layer.Neurons = append(layer.Neurons, NewLinearNeuron(context, 3, false, NoneActivation))
+	if _, err := layer.ExecuteBatched(NewTensorEmpty(6).Reshape(2, 3)); err == nil {
+		t.Error("expected an error for neurons with different bias use")
+	}
+
+	manual := &LinearLayer{Neurons: []*LinearNeuron{NewLinearNeuron(context, 3, true, ReluActivation)}}
+	if _, err := manual.ExecuteBatched(NewTensorEmpty(6).Reshape(2, 3)); err == nil {
+		t.Error("expected an error for a layer without an activation")
+	}
+
+	if _, err := layer.Execute(NewTensorEmpty(6).Reshape(2, 3)); err == nil {
+		t.Error("expected Execute to keep rejecting input that does not match the neuron size")
+	}
+}
@@ -1,5 +1,12 @@
 package nn
 
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
 type LossFunction = func(module ICallable, ys []*Tensor, xs []*Tensor, batchSize int) (*Tensor, error)
 
 func CrossEntropyLoss(module ICallable, ys []*Tensor, xs []*Tensor, batchSize int) (*Tensor, error) {
@@ -46,3 +53,69 @@ func CategoricalCrossEntropyLoss(module ICallable, ys []*Tensor, xs []*Tensor, b
 
 	return result, nil
 }
+
+// Mean negative log likelihood of the target class ids under a softmax over the last axis of logits
+// [...,C], computed in one stable step. Targets equal to ignoreIndex do not count (use -1 to keep all)
+func SoftmaxCrossEntropy(logits *Tensor, targets *Tensor, ignoreIndex int) (*Tensor, error) {
+	shape := logits.Shape()
+	classes := shape[len(shape)-1]
+	rows := len(logits.Backing.Backing) / classes
+	if len(targets.Backing.Backing) != rows {
+		return nil, fmt.Errorf("cross entropy expected %d targets for logits %v, got %d", rows, shape, len(targets.Backing.Backing))
+	}
+
+	probabilities := make([]float64, len(logits.Backing.Backing))
+	loss, counted := 0.0, 0
+	for row := 0; row < rows; row++ {
+		target := int(targets.Backing.Backing[row])
+		if target == ignoreIndex {
+			continue
+		}
+
+		if target < 0 || target >= classes {
+			return nil, fmt.Errorf("cross entropy target %d is not in range [0,%d)", target, classes)
+		}
+
+		values := logits.Backing.Backing[row*classes : (row+1)*classes]
+		largest := slices.Max(values)
+		sum := 0.0
+		for _, value := range values {
+			sum += math.Exp(value - largest)
+		}
+
+		for i, value := range values {
+			probabilities[row*classes+i] = math.Exp(value-largest) / sum
+		}
+
+		loss += largest + math.Log(sum) - values[target]
+		counted++
+	}
+
+	if counted == 0 {
+		return nil, errors.New("cross entropy has no targets left after ignoring")
+	}
+
+	return &Tensor{
+		Backing:   NewNArray([]float64{loss / float64(counted)}),
+		Gradients: make([]float64, 1),
+		Children:  []*Tensor{logits},
+		backward: func(parent *Tensor) {
+			scale := parent.Gradients[0] / float64(counted)
+			for row := 0; row < rows; row++ {
+				target := int(targets.Backing.Backing[row])
+				if target == ignoreIndex {
+					continue
+				}
+
+				for i := 0; i < classes; i++ {
+					gradient := probabilities[row*classes+i]
+					if i == target {
+						gradient--
+					}
+
+					logits.Gradients[row*classes+i] += scale * gradient
+				}
+			}
+		},
+	}, nil
+}
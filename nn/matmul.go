@@ -0,0 +1,185 @@
+package nn
+
+import (
+	"fmt"
+	"slices"
+)
+
+// Multiplies [...,M,K] by [...,K,N] matrix by matrix, the second operand may also be a single [K,N]
+// matrix shared by every batch entry
+func MatMul(first *Tensor, second *Tensor) (*Tensor, error) {
+	a, b := first.Shape(), second.Shape()
+	if len(a) < 2 || len(b) < 2 {
+		return nil, fmt.Errorf("matmul expects at least 2 dimensional operands, got %v and %v", a, b)
+	}
+
+	shared := len(b) == 2 && len(a) > 2
+	if !shared && !slices.Equal(a[:len(a)-2], b[:len(b)-2]) {
+		return nil, fmt.Errorf("matmul batch dimensions of %v and %v do not match", a, b)
+	}
+
+	m, k, n := a[len(a)-2], a[len(a)-1], b[len(b)-1]
+	if b[len(b)-2] != k {
+		return nil, fmt.Errorf("matmul inner dimensions of %v and %v do not match", a, b)
+	}
+
+	batches := len(first.Backing.Backing) / (m * k)
+	secondOffset := func(batch int) int {
+		if shared {
+			return 0
+		}
+
+		return batch * k * n
+	}
+
+	x, y := first.Backing.Backing, second.Backing.Backing
+	result := make([]float64, batches*m*n)
+	for batch := 0; batch < batches; batch++ {
+		left, right, out := x[batch*m*k:], y[secondOffset(batch):], result[batch*m*n:]
+		for i := 0; i < m; i++ {
+			for p := 0; p < k; p++ {
+				value := left[i*k+p]
+				for j := 0; j < n; j++ {
+					out[i*n+j] += value * right[p*n+j]
+				}
+			}
+		}
+	}
+
+	return &Tensor{
+		Backing:   NewNArray(result).Reshape(append(slices.Clone(a[:len(a)-1]), n)...),
+		Gradients: make([]float64, len(result)),
+		Children:  []*Tensor{first, second},
+		backward: func(parent *Tensor) {
+			for batch := 0; batch < batches; batch++ {
+				left, right := x[batch*m*k:], y[secondOffset(batch):]
+				leftGradients, rightGradients := first.Gradients[batch*m*k:], second.Gradients[secondOffset(batch):]
+				upstream := parent.Gradients[batch*m*n:]
+				for i := 0; i < m; i++ {
+					for p := 0; p < k; p++ {
+						sum := 0.0
+						for j := 0; j < n; j++ {
+							sum += upstream[i*n+j] * right[p*n+j]
+							rightGradients[p*n+j] += left[i*k+p] * upstream[i*n+j]
+						}
+
+						leftGradients[i*k+p] += sum
+					}
+				}
+			}
+		},
+	}, nil
+}
+
+func Transpose(tensor *Tensor, firstAxis int, secondAxis int) (*Tensor, error) {
+	shape := slices.Clone(tensor.Shape())
+	if firstAxis < 0 {
+		firstAxis += len(shape)
+	}
+
+	if secondAxis < 0 {
+		secondAxis += len(shape)
+	}
+
+	if firstAxis < 0 || secondAxis < 0 || firstAxis >= len(shape) || secondAxis >= len(shape) {
+		return nil, fmt.Errorf("transpose axes %d and %d are out of range for shape %v", firstAxis, secondAxis, shape)
+	}
+
+	outputShape := slices.Clone(shape)
+	outputShape[firstAxis], outputShape[secondAxis] = shape[secondAxis], shape[firstAxis]
+
+	// For every output position the flat index it is read from
+	sources := make([]int, len(tensor.Backing.Backing))
+	position := make([]int, len(shape))
+	for i := range sources {
+		unravelIndex(i, outputShape, position)
+		position[firstAxis], position[secondAxis] = position[secondAxis], position[firstAxis]
+		index := 0
+		for axis, coordinate := range position {
+			index = index*shape[axis] + coordinate
+		}
+
+		sources[i] = index
+	}
+
+	result := make([]float64, len(sources))
+	for i, source := range sources {
+		result[i] = tensor.Backing.Backing[source]
+	}
+
+	return &Tensor{
+		Backing:   NewNArray(result).Reshape(outputShape...),
+		Gradients: make([]float64, len(result)),
+		Children:  []*Tensor{tensor},
+		backward: func(parent *Tensor) {
+			for i, source := range sources {
+				tensor.Gradients[source] += parent.Gradients[i]
+			}
+		},
+	}, nil
+}
+
+// Applies input @ weights^T + bias over the last axis of [...,in] input with weights laid out [out,in]
+func Linear(input *Tensor, weights *Tensor, bias *Tensor) (*Tensor, error) {
+	shape := input.Shape()
+	weightShape := weights.Shape()
+	if len(weightShape) != 2 || shape[len(shape)-1] != weightShape[1] {
+		return nil, fmt.Errorf("linear expected input ending in %d for weights %v, got %v", weightShape[len(weightShape)-1], weightShape, shape)
+	}
+
+	outputs, inputs := weightShape[0], weightShape[1]
+	if bias != nil && len(bias.Backing.Backing) != outputs {
+		return nil, fmt.Errorf("linear expected %d bias values, got %d", outputs, len(bias.Backing.Backing))
+	}
+
+	rows := len(input.Backing.Backing) / inputs
+	x, w := input.Backing.Backing, weights.Backing.Backing
+	result := make([]float64, rows*outputs)
+	for row := 0; row < rows; row++ {
+		values := x[row*inputs : (row+1)*inputs]
+		for o := 0; o < outputs; o++ {
+			sum := 0.0
+			if bias != nil {
+				sum = bias.Backing.Backing[o]
+			}
+
+			for i, value := range values {
+				sum += w[o*inputs+i] * value
+			}
+
+			result[row*outputs+o] = sum
+		}
+	}
+
+	children := []*Tensor{input, weights}
+	if bias != nil {
+		children = append(children, bias)
+	}
+
+	return &Tensor{
+		Backing:   NewNArray(result).Reshape(append(slices.Clone(shape[:len(shape)-1]), outputs)...),
+		Gradients: make([]float64, len(result)),
+		Children:  children,
+		backward: func(parent *Tensor) {
+			for row := 0; row < rows; row++ {
+				values := x[row*inputs : (row+1)*inputs]
+				inputGradients := input.Gradients[row*inputs : (row+1)*inputs]
+				for o := 0; o < outputs; o++ {
+					upstream := parent.Gradients[row*outputs+o]
+					if upstream == 0 {
+						continue
+					}
+
+					if bias != nil {
+						bias.Gradients[o] += upstream
+					}
+
+					for i, value := range values {
+						weights.Gradients[o*inputs+i] += upstream * value
+						inputGradients[i] += upstream * w[o*inputs+i]
+					}
+				}
+			}
+		},
+	}, nil
+}
@@ -24,6 +24,20 @@ func (module *Module) UpdateParameters(updateCallback UpdateTensorFunction) {
 	}
 }
 
+func (module *Module) SetTraining(training bool) {
+	for i := range module.Layers {
+		module.Layers[i].SetTraining(training)
+	}
+}
+
+func (module *Module) Train() {
+	module.SetTraining(true)
+}
+
+func (module *Module) Eval() {
+	module.SetTraining(false)
+}
+
 func (module *Module) Execute(tensor *Tensor) (*Tensor, error) {
 	result := tensor
 	var err error
@@ -37,3 +51,26 @@ func (module *Module) Execute(tensor *Tensor) (*Tensor, error) {
 
 	return result, nil
 }
+
+// Runs the layers over a set of named tensors, single tensor layers transform MainTensor while layers
+// implementing IMultiCallable see every tensor so far. Side inputs stay available throughout, so a
+// KeyTensor or ValueTensor reaches every later attention layer, use GraphModule.AddBound to feed one layer
+func (module *Module) ExecuteMany(inputs NamedTensors) (NamedTensors, error) {
+	tensors := make(NamedTensors, len(inputs))
+	for name, tensor := range inputs {
+		tensors[name] = tensor
+	}
+
+	for i := range module.Layers {
+		outputs, err := ExecuteMany(module.Layers[i], tensors)
+		if err != nil {
+			return nil, err
+		}
+
+		for name, tensor := range outputs {
+			tensors[name] = tensor
+		}
+	}
+
+	return tensors, nil
+}
@@ -0,0 +1,48 @@
+package nn
+
+// Adapts a single tensor layer to read and write other named tensors, e.g. to project a side input
+type NamedLayer struct {
+	Layer   ICallable
+	Input   string
+	Output  string
+	context *NeuralContext
+}
+
+func NewNamedLayer(context *NeuralContext, layer ICallable, input string, output string) *NamedLayer {
+	return &NamedLayer{
+		Layer:   layer,
+		Input:   input,
+		Output:  output,
+		context: context,
+	}
+}
+
+func (layer *NamedLayer) Zerograd() {
+	layer.Layer.Zerograd()
+}
+
+func (layer *NamedLayer) UpdateParameters(updateCallback UpdateTensorFunction) {
+	layer.Layer.UpdateParameters(updateCallback)
+}
+
+func (layer *NamedLayer) SetTraining(training bool) {
+	layer.Layer.SetTraining(training)
+}
+
+func (layer *NamedLayer) Execute(tensor *Tensor) (*Tensor, error) {
+	return layer.Layer.Execute(tensor)
+}
+
+func (layer *NamedLayer) ExecuteMany(inputs NamedTensors) (NamedTensors, error) {
+	tensor, err := inputs.Require(layer.Input)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := layer.Layer.Execute(tensor)
+	if err != nil {
+		return nil, err
+	}
+
+	return NamedTensors{layer.Output: result}, nil
+}
@@ -92,6 +92,14 @@ func (t *NArray) Reshape(dims ...int) *NArray {
 	return t
 }
 
+func (t *NArray) Shape() []int {
+	if len(t.Dimensions) == 0 || GetTotalElements(t.Dimensions) != len(t.Backing) {
+		return []int{len(t.Backing)}
+	}
+
+	return t.Dimensions
+}
+
 func (t *NArray) Flatten() *NArray {
 	t.Dimensions = []int{len(t.Backing)}
 
@@ -0,0 +1,260 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Shared by the per-sample normalization layers, the input is split into contiguous rows that are
+// normalized independently and each element picks its affine parameter through parameterIndex
+type normLayer struct {
+	Weights *Tensor
+	Bias    *Tensor
+	Epsilon float64
+	context *NeuralContext
+}
+
+func newNormLayer(context *NeuralContext, parameters int, epsilon float64, affine bool, useBias bool) normLayer {
+	layer := normLayer{
+		Epsilon: epsilon,
+		context: context,
+	}
+
+	if affine {
+		layer.Weights = NewTensorWithValue(parameters, 1)
+		if useBias {
+			layer.Bias = NewTensorEmpty(parameters)
+		}
+	}
+
+	return layer
+}
+
+func (layer *normLayer) IsAffine() bool {
+	return layer.Weights != nil
+}
+
+func (layer *normLayer) Zerograd() {
+	if layer.Weights != nil {
+		layer.Weights.Zerograd()
+	}
+
+	if layer.Bias != nil {
+		layer.Bias.Zerograd()
+	}
+}
+
+func (layer *normLayer) UpdateParameters(updateCallback UpdateTensorFunction) {
+	if layer.Weights != nil {
+		updateCallback(layer.context, layer.Weights)
+	}
+
+	if layer.Bias != nil {
+		updateCallback(layer.context, layer.Bias)
+	}
+}
+
+func (layer *normLayer) SetTraining(training bool) {
+}
+
+func (layer *normLayer) normalize(tensor *Tensor, rowSize int, centered bool, parameterIndex func(index int) int) *Tensor {
+	values := tensor.Backing.Backing
+	rows := len(values) / rowSize
+	n := float64(rowSize)
+	normalized := make([]float64, len(values))
+	inverseStd := make([]float64, rows)
+	result := make([]float64, len(values))
+
+	for row := 0; row < rows; row++ {
+		x := values[row*rowSize : (row+1)*rowSize]
+		mean := 0.0
+		if centered {
+			for _, value := range x {
+				mean += value
+			}
+
+			mean /= n
+		}
+
+		squares := 0.0
+		for _, value := range x {
+			squares += (value - mean) * (value - mean)
+		}
+
+		inverseStd[row] = 1 / math.Sqrt(squares/n+layer.Epsilon)
+		for i, value := range x {
+			index := row*rowSize + i
+			normalized[index] = (value - mean) * inverseStd[row]
+			result[index] = normalized[index]
+			if layer.Weights != nil {
+				result[index] *= layer.Weights.Backing.Backing[parameterIndex(index)]
+			}
+
+			if layer.Bias != nil {
+				result[index] += layer.Bias.Backing.Backing[parameterIndex(index)]
+			}
+		}
+	}
+
+	children := []*Tensor{tensor}
+	if layer.Weights != nil {
+		children = append(children, layer.Weights)
+	}
+
+	if layer.Bias != nil {
+		children = append(children, layer.Bias)
+	}
+
+	weights, bias := layer.Weights, layer.Bias
+	return &Tensor{
+		Backing:   NewNArray(result).Reshape(slices.Clone(tensor.Shape())...),
+		Gradients: make([]float64, len(result)),
+		Children:  children,
+		backward: func(parent *Tensor) {
+			upstream := make([]float64, rowSize)
+			for row := 0; row < rows; row++ {
+				sum, dot := 0.0, 0.0
+				for i := range upstream {
+					index := row*rowSize + i
+					upstream[i] = parent.Gradients[index]
+					if weights != nil {
+						weights.Gradients[parameterIndex(index)] += parent.Gradients[index] * normalized[index]
+						upstream[i] *= weights.Backing.Backing[parameterIndex(index)]
+					}
+
+					if bias != nil {
+						bias.Gradients[parameterIndex(index)] += parent.Gradients[index]
+					}
+
+					sum += upstream[i]
+					dot += upstream[i] * normalized[index]
+				}
+
+				// Without centering the mean path vanishes and only the scale path through the norm remains
+				if !centered {
+					sum = 0
+				}
+
+				for i := range upstream {
+					index := row*rowSize + i
+					tensor.Gradients[index] += inverseStd[row] / n * (n*upstream[i] - sum - normalized[index]*dot)
+				}
+			}
+		},
+	}
+}
+
+func trailingShapeMatches(shape []int, normalizedShape []int) bool {
+	return len(shape) >= len(normalizedShape) && slices.Equal(shape[len(shape)-len(normalizedShape):], normalizedShape)
+}
+
+type LayerNormLayer struct {
+	normLayer
+	NormalizedShape []int
+}
+
+func NewLayerNormLayer(context *NeuralContext, normalizedShape []int, epsilon float64, affine bool) *LayerNormLayer {
+	if len(normalizedShape) == 0 {
+		panic("layer norm requires a normalized shape")
+	}
+
+	return &LayerNormLayer{
+		normLayer:       newNormLayer(context, GetTotalElements(normalizedShape), epsilon, affine, true),
+		NormalizedShape: slices.Clone(normalizedShape),
+	}
+}
+
+func (layer *LayerNormLayer) Execute(tensor *Tensor) (*Tensor, error) {
+	if !trailingShapeMatches(tensor.Shape(), layer.NormalizedShape) {
+		return nil, fmt.Errorf("layer norm expected input ending in %v, got %v", layer.NormalizedShape, tensor.Shape())
+	}
+
+	size := GetTotalElements(layer.NormalizedShape)
+	return layer.normalize(tensor, size, true, func(index int) int {
+		return index % size
+	}), nil
+}
+
+type RMSNormLayer struct {
+	normLayer
+	NormalizedShape []int
+}
+
+func NewRMSNormLayer(context *NeuralContext, normalizedShape []int, epsilon float64, affine bool) *RMSNormLayer {
+	if len(normalizedShape) == 0 {
+		panic("rms norm requires a normalized shape")
+	}
+
+	return &RMSNormLayer{
+		normLayer:       newNormLayer(context, GetTotalElements(normalizedShape), epsilon, affine, false),
+		NormalizedShape: slices.Clone(normalizedShape),
+	}
+}
+
+func (layer *RMSNormLayer) Execute(tensor *Tensor) (*Tensor, error) {
+	if !trailingShapeMatches(tensor.Shape(), layer.NormalizedShape) {
+		return nil, fmt.Errorf("rms norm expected input ending in %v, got %v", layer.NormalizedShape, tensor.Shape())
+	}
+
+	size := GetTotalElements(layer.NormalizedShape)
+	return layer.normalize(tensor, size, false, func(index int) int {
+		return index % size
+	}), nil
+}
+
+type GroupNormLayer struct {
+	normLayer
+	Groups   int
+	Channels int
+}
+
+func NewGroupNormLayer(context *NeuralContext, groups int, channels int, epsilon float64, affine bool) *GroupNormLayer {
+	if groups <= 0 || channels%groups != 0 {
+		panic(fmt.Sprintf("group norm channels %d must be divisible by groups %d", channels, groups))
+	}
+
+	return &GroupNormLayer{
+		normLayer: newNormLayer(context, channels, epsilon, affine, true),
+		Groups:    groups,
+		Channels:  channels,
+	}
+}
+
+// Expects the channel dimension at channelAxis, everything after it is normalized together with the group
+func (layer *GroupNormLayer) execute(tensor *Tensor, channelAxis int) *Tensor {
+	inner := 1
+	for _, dim := range tensor.Shape()[channelAxis+1:] {
+		inner *= dim
+	}
+
+	return layer.normalize(tensor, layer.Channels/layer.Groups*inner, true, func(index int) int {
+		return (index / inner) % layer.Channels
+	})
+}
+
+func (layer *GroupNormLayer) Execute(tensor *Tensor) (*Tensor, error) {
+	shape := tensor.Shape()
+	if len(shape) < 2 || shape[1] != layer.Channels {
+		return nil, fmt.Errorf("group norm expected input of shape [B,%d,...], got %v", layer.Channels, shape)
+	}
+
+	return layer.execute(tensor, 1), nil
+}
+
+type InstanceNorm2DLayer struct {
+	GroupNormLayer
+}
+
+func NewInstanceNorm2DLayer(context *NeuralContext, channels int, epsilon float64, affine bool) *InstanceNorm2DLayer {
+	return &InstanceNorm2DLayer{*NewGroupNormLayer(context, channels, channels, epsilon, affine)}
+}
+
+func (layer *InstanceNorm2DLayer) Execute(tensor *Tensor) (*Tensor, error) {
+	shape := tensor.Shape()
+	if (len(shape) != 3 && len(shape) != 4) || shape[len(shape)-3] != layer.Channels {
+		return nil, fmt.Errorf("instance norm expected input of shape [B,%d,H,W] or [%d,H,W], got %v", layer.Channels, layer.Channels, shape)
+	}
+
+	return layer.execute(tensor, len(shape)-3), nil
+}
@@ -0,0 +1,43 @@
+package nn
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestNormGradients(t *testing.T) {
+	context := NewNeuralContext(0)
+	layerNorm := NewLayerNormLayer(context, []int{2, 4}, 1e-5, true)
+	rmsNorm := NewRMSNormLayer(context, []int{5}, 1e-5, true)
+	groupNorm := NewGroupNormLayer(context, 2, 4, 1e-5, true)
+	instanceNorm := NewInstanceNorm2DLayer(context, 2, 1e-5, true)
+	plainNorm := NewLayerNormLayer(context, []int{6}, 1e-5, false)
+	cases := []struct {
+		name  string
+		layer ICallable
+		norm  *normLayer
+		dims  []int
+	}{
+		{"layer norm", layerNorm, &layerNorm.normLayer, []int{3, 2, 4}},
+		{"rms norm", rmsNorm, &rmsNorm.normLayer, []int{3, 5}},
+		{"group norm", groupNorm, &groupNorm.normLayer, []int{2, 4, 3}},
+		{"instance norm", instanceNorm, &instanceNorm.normLayer, []int{2, 2, 3, 3}},
+		{"layer norm without affine", plainNorm, &plainNorm.normLayer, []int{2, 6}},
+	}
+
+	for _, c := range cases {
+		random := rand.New(rand.NewSource(1))
+		input := randomTensor(random, c.dims...)
+		parameters := []*Tensor{input}
+		for _, parameter := range []*Tensor{c.norm.Weights, c.norm.Bias} {
+			if parameter != nil {
+				copy(parameter.Backing.Backing, randomTensor(random, len(parameter.Backing.Backing)).Backing.Backing)
+				parameters = append(parameters, parameter)
+			}
+		}
+
+		checkGradients(t, c.name, parameters, func() (*Tensor, error) {
+			return c.layer.Execute(input)
+		})
+	}
+}
@@ -14,6 +14,9 @@ func (layer *NormalizeLayer) Zerograd() {
 func (layer *NormalizeLayer) UpdateParameters(updateCallback UpdateTensorFunction) {
 }
 
+func (layer *NormalizeLayer) SetTraining(training bool) {
+}
+
 func (layer *NormalizeLayer) Execute(tensor *Tensor) (*Tensor, error) {
 	res, err := TensorDiv(tensor, tensor.MaxValue())
 
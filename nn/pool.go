@@ -0,0 +1,283 @@
+package nn
+
+import "fmt"
+
+type PoolMode int
+
+const (
+	MaxPool PoolMode = iota
+	AvgPool
+)
+
+type poolWindow struct {
+	start int
+	end   int
+	size  int
+}
+
+type poolAxis func(input int) ([]poolWindow, error)
+
+func fixedPoolAxis(kernel int, stride int, padding int) (poolAxis, error) {
+	if kernel <= 0 || stride <= 0 {
+		return nil, fmt.Errorf("pool kernel %d and stride %d must be positive", kernel, stride)
+	}
+
+	if padding < 0 || 2*padding > kernel {
+		return nil, fmt.Errorf("pool padding %d must be between 0 and half of kernel %d", padding, kernel)
+	}
+
+	return func(input int) ([]poolWindow, error) {
+		if input+2*padding < kernel {
+			return nil, fmt.Errorf("pool kernel %d does not fit into padded input %d", kernel, input+2*padding)
+		}
+
+		windows := make([]poolWindow, (input+2*padding-kernel)/stride+1)
+		for i := range windows {
+			start := i*stride - padding
+			windows[i] = poolWindow{start: max(start, 0), end: min(start+kernel, input), size: kernel}
+		}
+
+		return windows, nil
+	}, nil
+}
+
+func adaptivePoolAxis(output int) (poolAxis, error) {
+	if output <= 0 {
+		return nil, fmt.Errorf("adaptive pool output size %d must be positive", output)
+	}
+
+	return func(input int) ([]poolWindow, error) {
+		windows := make([]poolWindow, output)
+		for i := range windows {
+			start := i * input / output
+			end := ((i+1)*input + output - 1) / output
+			windows[i] = poolWindow{start: start, end: end, size: end - start}
+		}
+
+		return windows, nil
+	}, nil
+}
+
+// Lists the flat plane indices read by every output position along with the averaging divisor
+func poolMembers(spatial []int, windows [][]poolWindow) ([][]int, []float64) {
+	outputs := make([]int, len(windows))
+	for i := range windows {
+		outputs[i] = len(windows[i])
+	}
+
+	count := GetTotalElements(outputs)
+	members, divisors := make([][]int, count), make([]float64, count)
+	position := make([]int, len(spatial))
+	for p := 0; p < count; p++ {
+		unravelIndex(p, outputs, position)
+		indices := []int{0}
+		divisor := 1
+		for axis := range spatial {
+			window := windows[axis][position[axis]]
+			divisor *= window.size
+			next := make([]int, 0, len(indices)*(window.end-window.start))
+			for _, index := range indices {
+				for coordinate := window.start; coordinate < window.end; coordinate++ {
+					next = append(next, index*spatial[axis]+coordinate)
+				}
+			}
+
+			indices = next
+		}
+
+		members[p], divisors[p] = indices, float64(divisor)
+	}
+
+	return members, divisors
+}
+
+func pool(tensor *Tensor, axes []poolAxis, mode PoolMode, squeeze bool) (*Tensor, error) {
+	shape := tensor.Shape()
+	dims := len(axes)
+	if len(shape) < dims+1 || len(shape) > dims+2 {
+		return nil, fmt.Errorf("pool expected input with %d or %d dimensions, got %v", dims+1, dims+2, shape)
+	}
+
+	leading, spatial := shape[:len(shape)-dims], shape[len(shape)-dims:]
+	windows := make([][]poolWindow, dims)
+	output := make([]int, dims)
+	var err error
+	for i := range axes {
+		windows[i], err = axes[i](spatial[i])
+		if err != nil {
+			return nil, err
+		}
+
+		output[i] = len(windows[i])
+	}
+
+	members, divisors := poolMembers(spatial, windows)
+	planes, planeSize, outputSize := GetTotalElements(leading), GetTotalElements(spatial), len(members)
+	values := tensor.Backing.Backing
+	result := make([]float64, planes*outputSize)
+	var argmax []int
+	if mode == MaxPool {
+		argmax = make([]int, len(result))
+	}
+
+	for plane := 0; plane < planes; plane++ {
+		base := plane * planeSize
+		for p, indices := range members {
+			if mode == AvgPool {
+				sum := 0.0
+				for _, index := range indices {
+					sum += values[base+index]
+				}
+
+				result[plane*outputSize+p] = sum / divisors[p]
+				continue
+			}
+
+			// Start from the first member so a window of NaN or -Inf values still routes its gradient
+			best, bestIndex := values[base+indices[0]], base+indices[0]
+			for _, index := range indices[1:] {
+				if values[base+index] > best {
+					best, bestIndex = values[base+index], base+index
+				}
+			}
+
+			result[plane*outputSize+p], argmax[plane*outputSize+p] = best, bestIndex
+		}
+	}
+
+	outputDims := append(append([]int{}, leading...), output...)
+	if squeeze {
+		outputDims = append([]int{}, leading...)
+	}
+
+	return &Tensor{
+		Backing:   NewNArray(result).Reshape(outputDims...),
+		Gradients: make([]float64, len(result)),
+		Children:  []*Tensor{tensor},
+		backward: func(parent *Tensor) {
+			if mode == MaxPool {
+				// Only the element that won the max receives gradient
+				for i, index := range argmax {
+					tensor.Gradients[index] += parent.Gradients[i]
+				}
+
+				return
+			}
+
+			for plane := 0; plane < planes; plane++ {
+				for p, indices := range members {
+					gradient := parent.Gradients[plane*outputSize+p] / divisors[p]
+					for _, index := range indices {
+						tensor.Gradients[plane*planeSize+index] += gradient
+					}
+				}
+			}
+		},
+	}, nil
+}
+
+type PoolLayer struct {
+	Mode    PoolMode
+	axes    []poolAxis
+	squeeze bool
+	context *NeuralContext
+}
+
+func newPoolLayer(context *NeuralContext, mode PoolMode, axes ...poolAxis) *PoolLayer {
+	return &PoolLayer{
+		Mode:    mode,
+		axes:    axes,
+		context: context,
+	}
+}
+
+func newFixedPoolLayer(context *NeuralContext, mode PoolMode, dims int, kernelSize int, stride int, padding int) (*PoolLayer, error) {
+	axis, err := fixedPoolAxis(kernelSize, stride, padding)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPoolLayer(context, mode, repeatAxis(axis, dims)...), nil
+}
+
+func newAdaptivePoolLayer(context *NeuralContext, mode PoolMode, outputSizes ...int) (*PoolLayer, error) {
+	axes := make([]poolAxis, len(outputSizes))
+	for i, outputSize := range outputSizes {
+		axis, err := adaptivePoolAxis(outputSize)
+		if err != nil {
+			return nil, err
+		}
+
+		axes[i] = axis
+	}
+
+	return newPoolLayer(context, mode, axes...), nil
+}
+
+func repeatAxis(axis poolAxis, count int) []poolAxis {
+	axes := make([]poolAxis, count)
+	for i := range axes {
+		axes[i] = axis
+	}
+
+	return axes
+}
+
+func NewMaxPool1DLayer(context *NeuralContext, kernelSize int, stride int, padding int) (*PoolLayer, error) {
+	return newFixedPoolLayer(context, MaxPool, 1, kernelSize, stride, padding)
+}
+
+func NewAvgPool1DLayer(context *NeuralContext, kernelSize int, stride int, padding int) (*PoolLayer, error) {
+	return newFixedPoolLayer(context, AvgPool, 1, kernelSize, stride, padding)
+}
+
+func NewAdaptiveMaxPool1DLayer(context *NeuralContext, outputSize int) (*PoolLayer, error) {
+	return newAdaptivePoolLayer(context, MaxPool, outputSize)
+}
+
+func NewAdaptiveAvgPool1DLayer(context *NeuralContext, outputSize int) (*PoolLayer, error) {
+	return newAdaptivePoolLayer(context, AvgPool, outputSize)
+}
+
+func NewMaxPool2DLayer(context *NeuralContext, kernelSize int, stride int, padding int) (*PoolLayer, error) {
+	return newFixedPoolLayer(context, MaxPool, 2, kernelSize, stride, padding)
+}
+
+func NewAvgPool2DLayer(context *NeuralContext, kernelSize int, stride int, padding int) (*PoolLayer, error) {
+	return newFixedPoolLayer(context, AvgPool, 2, kernelSize, stride, padding)
+}
+
+func NewAdaptiveMaxPool2DLayer(context *NeuralContext, outputHeight int, outputWidth int) (*PoolLayer, error) {
+	return newAdaptivePoolLayer(context, MaxPool, outputHeight, outputWidth)
+}
+
+func NewAdaptiveAvgPool2DLayer(context *NeuralContext, outputHeight int, outputWidth int) (*PoolLayer, error) {
+	return newAdaptivePoolLayer(context, AvgPool, outputHeight, outputWidth)
+}
+
+func NewGlobalAvgPoolLayer(context *NeuralContext, spatialDims int) (*PoolLayer, error) {
+	if spatialDims <= 0 {
+		return nil, fmt.Errorf("global pool needs at least one spatial dimension, got %d", spatialDims)
+	}
+
+	layer, err := newAdaptivePoolLayer(context, AvgPool, Fill(make([]int, spatialDims), 1)...)
+	if err != nil {
+		return nil, err
+	}
+
+	layer.squeeze = true
+	return layer, nil
+}
+
+func (layer *PoolLayer) Zerograd() {
+}
+
+func (layer *PoolLayer) UpdateParameters(updateCallback UpdateTensorFunction) {
+}
+
+func (layer *PoolLayer) SetTraining(training bool) {
+}
+
+func (layer *PoolLayer) Execute(tensor *Tensor) (*Tensor, error) {
+	return pool(tensor, layer.axes, layer.Mode, layer.squeeze)
+}
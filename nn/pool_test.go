@@ -0,0 +1,55 @@
+package nn
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestPoolGradients(t *testing.T) {
+	context := NewNeuralContext(0)
+	cases := []struct {
+		name  string
+		build func() (*PoolLayer, error)
+		dims  []int
+	}{
+		{"max 1d", func() (*PoolLayer, error) { return NewMaxPool1DLayer(context, 3, 2, 1) }, []int{2, 7}},
+		{"avg 1d", func() (*PoolLayer, error) { return NewAvgPool1DLayer(context, 2, 1, 1) }, []int{2, 5}},
+		{"adaptive max 1d", func() (*PoolLayer, error) { return NewAdaptiveMaxPool1DLayer(context, 3) }, []int{2, 7}},
+		{"adaptive avg 1d", func() (*PoolLayer, error) { return NewAdaptiveAvgPool1DLayer(context, 4) }, []int{2, 6}},
+		{"max 2d", func() (*PoolLayer, error) { return NewMaxPool2DLayer(context, 2, 2, 0) }, []int{2, 2, 4, 4}},
+		{"avg 2d", func() (*PoolLayer, error) { return NewAvgPool2DLayer(context, 3, 2, 1) }, []int{2, 5, 5}},
+		{"adaptive max 2d", func() (*PoolLayer, error) { return NewAdaptiveMaxPool2DLayer(context, 2, 3) }, []int{2, 5, 5}},
+		{"adaptive avg 2d", func() (*PoolLayer, error) { return NewAdaptiveAvgPool2DLayer(context, 3, 2) }, []int{2, 4, 5}},
+		{"global avg", func() (*PoolLayer, error) { return NewGlobalAvgPoolLayer(context, 2) }, []int{2, 3, 3, 4}},
+	}
+
+	for _, c := range cases {
+		layer, err := c.build()
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		input := randomTensor(rand.New(rand.NewSource(1)), c.dims...)
+		checkGradients(t, c.name, []*Tensor{input}, func() (*Tensor, error) {
+			return layer.Execute(input)
+		})
+	}
+}
+
+func TestPoolRejectsInvalidArguments(t *testing.T) {
+	context := NewNeuralContext(0)
+	cases := map[string]func() (*PoolLayer, error){
+		"zero kernel":        func() (*PoolLayer, error) { return NewMaxPool2DLayer(context, 0, 1, 0) },
+		"zero stride":        func() (*PoolLayer, error) { return NewAvgPool1DLayer(context, 2, 0, 0) },
+		"negative padding":   func() (*PoolLayer, error) { return NewMaxPool1DLayer(context, 2, 1, -1) },
+		"oversized padding":  func() (*PoolLayer, error) { return NewAvgPool2DLayer(context, 2, 1, 2) },
+		"zero adaptive size": func() (*PoolLayer, error) { return NewAdaptiveMaxPool2DLayer(context, 0, 2) },
+		"no spatial dims":    func() (*PoolLayer, error) { return NewGlobalAvgPoolLayer(context, 0) },
+	}
+
+	for name, build := range cases {
+		if _, err := build(); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
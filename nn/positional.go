@@ -0,0 +1,189 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+	"slices"
+)
+
+// Adds the first T rows of a [maxLength,E] table to [...,T,E] input, a fixed table stays out of the graph
+// so repeated backward passes do not pile gradients into it
+func addPositions(tensor *Tensor, table *Tensor, learned bool) (*Tensor, error) {
+	shape := tensor.Shape()
+	tableShape := table.Shape()
+	if len(shape) < 2 || shape[len(shape)-1] != tableShape[1] || shape[len(shape)-2] > tableShape[0] {
+		return nil, fmt.Errorf("positional encoding of %v positions and size %d does not fit input %v", tableShape[0], tableShape[1], shape)
+	}
+
+	size := shape[len(shape)-2] * shape[len(shape)-1]
+	result := make([]float64, len(tensor.Backing.Backing))
+	for i, value := range tensor.Backing.Backing {
+		result[i] = value + table.Backing.Backing[i%size]
+	}
+
+	children := []*Tensor{tensor}
+	if learned {
+		children = append(children, table)
+	}
+
+	return &Tensor{
+		Backing:   NewNArray(result).Reshape(slices.Clone(shape)...),
+		Gradients: make([]float64, len(result)),
+		Children:  children,
+		backward: func(parent *Tensor) {
+			for i, gradient := range parent.Gradients {
+				tensor.Gradients[i] += gradient
+				if learned {
+					table.Gradients[i%size] += gradient
+				}
+			}
+		},
+	}, nil
+}
+
+type SinusoidalPositionalEncoding struct {
+	MaxLength int
+	EmbedDim  int
+	table     *Tensor
+	context   *NeuralContext
+}
+
+func NewSinusoidalPositionalEncoding(context *NeuralContext, maxLength int, embedDim int) *SinusoidalPositionalEncoding {
+	table := NewTensorEmpty(maxLength*embedDim).Reshape(maxLength, embedDim)
+	for position := 0; position < maxLength; position++ {
+		for i := 0; i < embedDim; i++ {
+			angle := float64(position) / math.Pow(10000, float64(i-i%2)/float64(embedDim))
+			if i%2 == 0 {
+				table.Backing.Backing[position*embedDim+i] = math.Sin(angle)
+			} else {
+				table.Backing.Backing[position*embedDim+i] = math.Cos(angle)
+			}
+		}
+	}
+
+	return &SinusoidalPositionalEncoding{
+		MaxLength: maxLength,
+		EmbedDim:  embedDim,
+		table:     table,
+		context:   context,
+	}
+}
+
+func (layer *SinusoidalPositionalEncoding) Zerograd() {
+}
+
+func (layer *SinusoidalPositionalEncoding) UpdateParameters(updateCallback UpdateTensorFunction) {
+}
+
+func (layer *SinusoidalPositionalEncoding) SetTraining(training bool) {
+}
+
+func (layer *SinusoidalPositionalEncoding) Execute(tensor *Tensor) (*Tensor, error) {
+	return addPositions(tensor, layer.table, false)
+}
+
+type LearnedPositionalEncoding struct {
+	Weights *Tensor
+	context *NeuralContext
+}
+
+func NewLearnedPositionalEncoding(context *NeuralContext, maxLength int, embedDim int) *LearnedPositionalEncoding {
+	weights := NewTensorEmpty(maxLength*embedDim).Reshape(maxLength, embedDim)
+	for i := range weights.Backing.Backing {
+		weights.Backing.Backing[i] = 0.02 * context.Random.NormFloat64()
+	}
+
+	return &LearnedPositionalEncoding{
+		Weights: weights,
+		context: context,
+	}
+}
+
+func (layer *LearnedPositionalEncoding) Zerograd() {
+	layer.Weights.Zerograd()
+}
+
+func (layer *LearnedPositionalEncoding) UpdateParameters(updateCallback UpdateTensorFunction) {
+	updateCallback(layer.context, layer.Weights)
+}
+
+func (layer *LearnedPositionalEncoding) SetTraining(training bool) {
+}
+
+func (layer *LearnedPositionalEncoding) Execute(tensor *Tensor) (*Tensor, error) {
+	return addPositions(tensor, layer.Weights, true)
+}
+
+// Rotates consecutive feature pairs of [...,T,D] input by an angle that grows with the position, used
+// on attention queries and keys so their dot product only depends on the relative offset
+type RotaryPositionalEncoding struct {
+	Dimension int
+	Base      float64
+	context   *NeuralContext
+}
+
+func NewRotaryPositionalEncoding(context *NeuralContext, dimension int, base float64) *RotaryPositionalEncoding {
+	if dimension <= 0 || dimension%2 != 0 {
+		panic(fmt.Sprintf("rotary encoding dimension %d must be even", dimension))
+	}
+
+	return &RotaryPositionalEncoding{
+		Dimension: dimension,
+		Base:      base,
+		context:   context,
+	}
+}
+
+func (layer *RotaryPositionalEncoding) Zerograd() {
+}
+
+func (layer *RotaryPositionalEncoding) UpdateParameters(updateCallback UpdateTensorFunction) {
+}
+
+func (layer *RotaryPositionalEncoding) SetTraining(training bool) {
+}
+
+func (layer *RotaryPositionalEncoding) Execute(tensor *Tensor) (*Tensor, error) {
+	shape := tensor.Shape()
+	if len(shape) < 2 || shape[len(shape)-1] != layer.Dimension {
+		return nil, fmt.Errorf("rotary encoding expected input of shape [...,T,%d], got %v", layer.Dimension, shape)
+	}
+
+	steps := shape[len(shape)-2]
+	cosines, sines := make([]float64, steps*layer.Dimension/2), make([]float64, steps*layer.Dimension/2)
+	for position := 0; position < steps; position++ {
+		for i := 0; i < layer.Dimension/2; i++ {
+			angle := float64(position) * math.Pow(layer.Base, -float64(2*i)/float64(layer.Dimension))
+			cosines[position*layer.Dimension/2+i], sines[position*layer.Dimension/2+i] = math.Cos(angle), math.Sin(angle)
+		}
+	}
+
+	// Pair p of the flattened input sits at position p / (D/2) within its sequence
+	angleOf := func(pair int) (float64, float64) {
+		index := pair % len(cosines)
+		return cosines[index], sines[index]
+	}
+
+	values := tensor.Backing.Backing
+	result := make([]float64, len(values))
+	for pair := 0; pair < len(values)/2; pair++ {
+		cosine, sine := angleOf(pair)
+		first, second := values[2*pair], values[2*pair+1]
+		result[2*pair] = first*cosine - second*sine
+		result[2*pair+1] = first*sine + second*cosine
+	}
+
+	return &Tensor{
+		Backing:   NewNArray(result).Reshape(slices.Clone(shape)...),
+		Gradients: make([]float64, len(result)),
+		Children:  []*Tensor{tensor},
+		backward: func(parent *Tensor) {
+			for pair := 0; pair < len(values)/2; pair++ {
+				cosine, sine := angleOf(pair)
+				first, second := parent.Gradients[2*pair], parent.Gradients[2*pair+1]
+				tensor.Gradients[2*pair] += first*cosine + second*sine
+				tensor.Gradients[2*pair+1] += -first*sine + second*cosine
+			}
+		},
+	}, nil
+}
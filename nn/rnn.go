@@ -0,0 +1,502 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+)
+
+type RecurrentCell int
+
+const (
+	RNNTanhCell RecurrentCell = iota
+	RNNReluCell
+	LSTMCell
+	GRUCell
+)
+
+func (cell RecurrentCell) gates() int {
+	switch cell {
+	case LSTMCell:
+		return 4
+	case GRUCell:
+		return 3
+	default:
+		return 1
+	}
+}
+
+// Gate rows are stacked like PyTorch, input/forget/cell/output for LSTM and reset/update/new for GRU
+type RecurrentWeights struct {
+	WeightsInput  *Tensor
+	WeightsHidden *Tensor
+	BiasInput     *Tensor
+	BiasHidden    *Tensor
+}
+
+func (weights *RecurrentWeights) tensors() []*Tensor {
+	tensors := []*Tensor{weights.WeightsInput, weights.WeightsHidden}
+	if weights.BiasInput != nil {
+		tensors = append(tensors, weights.BiasInput, weights.BiasHidden)
+	}
+
+	return tensors
+}
+
+// Hidden and Cell are [layers*directions,B,H], Cell is only used by LSTM
+type RecurrentState struct {
+	Hidden *Tensor
+	Cell   *Tensor
+}
+
+func (state *RecurrentState) Detach() *RecurrentState {
+	detached := &RecurrentState{Hidden: state.Hidden.Detach()}
+	if state.Cell != nil {
+		detached.Cell = state.Cell.Detach()
+	}
+
+	return detached
+}
+
+type recurrentLayer struct {
+	Weights       []*RecurrentWeights
+	Cell          RecurrentCell
+	InputSize     int
+	HiddenSize    int
+	Layers        int
+	Bidirectional bool
+	Stateful      bool
+	State         *RecurrentState
+	context       *NeuralContext
+}
+
+func newRecurrentLayer(context *NeuralContext, cell RecurrentCell, inputSize int, hiddenSize int, layers int, bidirectional bool, useBias bool) recurrentLayer {
+	if inputSize <= 0 || hiddenSize <= 0 || layers <= 0 {
+		panic(fmt.Sprintf("recurrent layer sizes must be positive, got input %d hidden %d layers %d", inputSize, hiddenSize, layers))
+	}
+
+	layer := recurrentLayer{
+		Cell:          cell,
+		InputSize:     inputSize,
+		HiddenSize:    hiddenSize,
+		Layers:        layers,
+		Bidirectional: bidirectional,
+		context:       context,
+	}
+
+	rows := cell.gates() * hiddenSize
+	bound := 1 / math.Sqrt(float64(hiddenSize))
+	for l := 0; l < layers; l++ {
+		inputs := inputSize
+		if l > 0 {
+			inputs = hiddenSize * layer.directions()
+		}
+
+		for d := 0; d < layer.directions(); d++ {
+			weights := &RecurrentWeights{
+				WeightsInput:  NewTensorUniform(rows*inputs, -bound, bound, context.Random).Reshape(rows, inputs),
+				WeightsHidden: NewTensorUniform(rows*hiddenSize, -bound, bound, context.Random).Reshape(rows, hiddenSize),
+			}
+
+			if useBias {
+				weights.BiasInput = NewTensorUniform(rows, -bound, bound, context.Random)
+				weights.BiasHidden = NewTensorUniform(rows, -bound, bound, context.Random)
+			}
+
+			layer.Weights = append(layer.Weights, weights)
+		}
+	}
+
+	return layer
+}
+
+func (layer *recurrentLayer) directions() int {
+	if layer.Bidirectional {
+		return 2
+	}
+
+	return 1
+}
+
+func (layer *recurrentLayer) ResetState() {
+	layer.State = nil
+}
+
+func (layer *recurrentLayer) Zerograd() {
+	for _, weights := range layer.Weights {
+		for _, tensor := range weights.tensors() {
+			tensor.Zerograd()
+		}
+	}
+}
+
+func (layer *recurrentLayer) UpdateParameters(updateCallback UpdateTensorFunction) {
+	for _, weights := range layer.Weights {
+		for _, tensor := range weights.tensors() {
+			updateCallback(layer.context, tensor)
+		}
+	}
+}
+
+func (layer *recurrentLayer) SetTraining(training bool) {
+}
+
+// Runs one layer in one direction over [B,T,F] input as a single graph node with hand written BPTT.
+// The result is laid out as all hidden states [B,T,H], then the final hidden state [B,H] and for LSTM
+// the final cell state [B,H], callers narrow it into those parts
+func (layer *recurrentLayer) direction(input *Tensor, weights *RecurrentWeights, h0 *Tensor, c0 *Tensor, reverse bool, batchSize int, steps int) *Tensor {
+	x := input.Backing.Backing
+	inputSize := len(x) / (batchSize * steps)
+	size := layer.HiddenSize
+	rows := layer.Cell.gates() * size
+	stateSize := batchSize * size
+	wi, wh := weights.WeightsInput.Backing.Backing, weights.WeightsHidden.Backing.Backing
+	timeOf := func(step int) int {
+		if reverse {
+			return steps - 1 - step
+		}
+
+		return step
+	}
+
+	// Slot s holds the state before processing step s, the last slot is the final state
+	hidden := make([]float64, (steps+1)*stateSize)
+	cell := make([]float64, (steps+1)*stateSize)
+	copy(hidden, h0.Backing.Backing)
+	if c0 != nil {
+		copy(cell, c0.Backing.Backing)
+	}
+
+	activations := make([]float64, steps*batchSize*rows)
+	recurrent := make([]float64, steps*stateSize)
+	pre, rec := make([]float64, rows), make([]float64, rows)
+	for s := 0; s < steps; s++ {
+		t := timeOf(s)
+		for b := 0; b < batchSize; b++ {
+			xt := x[(b*steps+t)*inputSize : (b*steps+t+1)*inputSize]
+			previous := hidden[s*stateSize+b*size : s*stateSize+(b+1)*size]
+			for r := 0; r < rows; r++ {
+				pre[r], rec[r] = 0, 0
+				for k, value := range xt {
+					pre[r] += wi[r*inputSize+k] * value
+				}
+
+				for k, value := range previous {
+					rec[r] += wh[r*size+k] * value
+				}
+
+				if weights.BiasInput != nil {
+					pre[r] += weights.BiasInput.Backing.Backing[r]
+					rec[r] += weights.BiasHidden.Backing.Backing[r]
+				}
+			}
+
+			act := activations[(s*batchSize+b)*rows : (s*batchSize+b+1)*rows]
+			h := hidden[(s+1)*stateSize+b*size : (s+1)*stateSize+(b+1)*size]
+			c := cell[(s+1)*stateSize+b*size : (s+1)*stateSize+(b+1)*size]
+			previousCell := cell[s*stateSize+b*size : s*stateSize+(b+1)*size]
+			for j := 0; j < size; j++ {
+				switch layer.Cell {
+				case RNNTanhCell:
+					act[j] = math.Tanh(pre[j] + rec[j])
+					h[j] = act[j]
+				case RNNReluCell:
+					act[j] = math.Max(0, pre[j]+rec[j])
+					h[j] = act[j]
+				case LSTMCell:
+					act[j] = sigmoid(pre[j] + rec[j])
+					act[size+j] = sigmoid(pre[size+j] + rec[size+j])
+					act[2*size+j] = math.Tanh(pre[2*size+j] + rec[2*size+j])
+					act[3*size+j] = sigmoid(pre[3*size+j] + rec[3*size+j])
+					c[j] = act[size+j]*previousCell[j] + act[j]*act[2*size+j]
+					h[j] = act[3*size+j] * math.Tanh(c[j])
+				case GRUCell:
+					act[j] = sigmoid(pre[j] + rec[j])
+					act[size+j] = sigmoid(pre[size+j] + rec[size+j])
+					recurrent[s*stateSize+b*size+j] = rec[2*size+j]
+					act[2*size+j] = math.Tanh(pre[2*size+j] + act[j]*rec[2*size+j])
+					h[j] = (1-act[size+j])*act[2*size+j] + act[size+j]*previous[j]
+				}
+			}
+		}
+	}
+
+	outputSize := steps * stateSize
+	result := make([]float64, outputSize+stateSize, outputSize+2*stateSize)
+	for s := 0; s < steps; s++ {
+		t := timeOf(s)
+		for b := 0; b < batchSize; b++ {
+			copy(result[(b*steps+t)*size:(b*steps+t+1)*size], hidden[(s+1)*stateSize+b*size:(s+1)*stateSize+(b+1)*size])
+		}
+	}
+
+	copy(result[outputSize:], hidden[steps*stateSize:])
+	if layer.Cell == LSTMCell {
+		result = append(result, cell[steps*stateSize:]...)
+	}
+
+	children := append([]*Tensor{input, h0}, weights.tensors()...)
+	if c0 != nil {
+		children = append(children, c0)
+	}
+
+	return &Tensor{
+		Backing:   NewNArray(result),
+		Gradients: make([]float64, len(result)),
+		Children:  children,
+		backward: func(parent *Tensor) {
+			dh := make([]float64, stateSize)
+			dc := make([]float64, stateSize)
+			copy(dh, parent.Gradients[outputSize:outputSize+stateSize])
+			if layer.Cell == LSTMCell {
+				copy(dc, parent.Gradients[outputSize+stateSize:])
+			}
+
+			dpre, drec := make([]float64, rows), make([]float64, rows)
+			previousGradient := make([]float64, size)
+			dwi, dwh := weights.WeightsInput.Gradients, weights.WeightsHidden.Gradients
+			for s := steps - 1; s >= 0; s-- {
+				t := timeOf(s)
+				for b := 0; b < batchSize; b++ {
+					gh := dh[b*size : (b+1)*size]
+					gc := dc[b*size : (b+1)*size]
+					upstream := parent.Gradients[(b*steps+t)*size : (b*steps+t+1)*size]
+					for j := range gh {
+						gh[j] += upstream[j]
+					}
+
+					act := activations[(s*batchSize+b)*rows : (s*batchSize+b+1)*rows]
+					previous := hidden[s*stateSize+b*size : s*stateSize+(b+1)*size]
+					Fill(previousGradient, 0)
+					for j := 0; j < size; j++ {
+						switch layer.Cell {
+						case RNNTanhCell:
+							dpre[j] = gh[j] * (1 - act[j]*act[j])
+							drec[j] = dpre[j]
+						case RNNReluCell:
+							dpre[j] = 0
+							if act[j] > 0 {
+								dpre[j] = gh[j]
+							}
+
+							drec[j] = dpre[j]
+						case LSTMCell:
+							i, f, g, o := act[j], act[size+j], act[2*size+j], act[3*size+j]
+							tanhCell := math.Tanh(cell[(s+1)*stateSize+b*size+j])
+							total := gc[j] + gh[j]*o*(1-tanhCell*tanhCell)
+							dpre[j] = total * g * i * (1 - i)
+							dpre[size+j] = total * cell[s*stateSize+b*size+j] * f * (1 - f)
+							dpre[2*size+j] = total * i * (1 - g*g)
+							dpre[3*size+j] = gh[j] * tanhCell * o * (1 - o)
+							for _, r := range []int{j, size + j, 2*size + j, 3*size + j} {
+								drec[r] = dpre[r]
+							}
+
+							gc[j] = total * f
+						case GRUCell:
+							r, z, n := act[j], act[size+j], act[2*size+j]
+							newGate := gh[j] * (1 - z) * (1 - n*n)
+							dpre[2*size+j] = newGate
+							drec[2*size+j] = newGate * r
+							dpre[j] = newGate * recurrent[s*stateSize+b*size+j] * r * (1 - r)
+							drec[j] = dpre[j]
+							dpre[size+j] = gh[j] * (previous[j] - n) * z * (1 - z)
+							drec[size+j] = dpre[size+j]
+							previousGradient[j] += gh[j] * z
+						}
+					}
+
+					xt := x[(b*steps+t)*inputSize : (b*steps+t+1)*inputSize]
+					dxt := input.Gradients[(b*steps+t)*inputSize : (b*steps+t+1)*inputSize]
+					for r := 0; r < rows; r++ {
+						if weights.BiasInput != nil {
+							weights.BiasInput.Gradients[r] += dpre[r]
+							weights.BiasHidden.Gradients[r] += drec[r]
+						}
+
+						for k, value := range xt {
+							dwi[r*inputSize+k] += dpre[r] * value
+							dxt[k] += wi[r*inputSize+k] * dpre[r]
+						}
+
+						for k, value := range previous {
+							dwh[r*size+k] += drec[r] * value
+							previousGradient[k] += wh[r*size+k] * drec[r]
+						}
+					}
+
+					copy(gh, previousGradient)
+				}
+			}
+
+			for i := range dh {
+				h0.Gradients[i] += dh[i]
+				if c0 != nil {
+					c0.Gradients[i] += dc[i]
+				}
+			}
+		},
+	}
+}
+
+// Picks the [B,H] slice of a [layers*directions,B,H] state, starting from zeros when there is none
+func initialState(state *Tensor, index int, stateSize int) (*Tensor, error) {
+	if state == nil {
+		return NewTensorEmpty(stateSize), nil
+	}
+
+	return Narrow(state, 0, index, 1)
+}
+
+// Runs the stack over [B,T,F] or unbatched [T,F] input and returns every top layer hidden state as
+// [B,T,directions*H] along with the final state of each layer and direction
+func (layer *recurrentLayer) Forward(tensor *Tensor, initial *RecurrentState) (*Tensor, *RecurrentState, error) {
+	shape := tensor.Shape()
+	if (len(shape) != 2 && len(shape) != 3) || shape[len(shape)-1] != layer.InputSize {
+		return nil, nil, fmt.Errorf("recurrent layer expected input of shape [B,T,%d] or [T,%d], got %v", layer.InputSize, layer.InputSize, shape)
+	}
+
+	batched := len(shape) == 3
+	batchSize, steps := 1, shape[0]
+	if batched {
+		batchSize, steps = shape[0], shape[1]
+	}
+
+	directions := layer.directions()
+	stateSize := batchSize * layer.HiddenSize
+	stateDims := []int{layer.Layers * directions, batchSize, layer.HiddenSize}
+	if !batched {
+		stateDims = []int{layer.Layers * directions, layer.HiddenSize}
+	}
+
+	var initialHidden, initialCell *Tensor
+	if initial != nil {
+		initialHidden, initialCell = initial.Hidden, initial.Cell
+		for _, state := range []*Tensor{initialHidden, initialCell} {
+			if state != nil && (state.Shape()[0] != stateDims[0] || len(state.Backing.Backing) != GetTotalElements(stateDims)) {
+				return nil, nil, fmt.Errorf("recurrent layer expected initial state of shape %v, got %v", stateDims, state.Shape())
+			}
+		}
+	}
+
+	input := tensor
+	hiddens := make([]*Tensor, 0, layer.Layers*directions)
+	cells := make([]*Tensor, 0, layer.Layers*directions)
+	for l := 0; l < layer.Layers; l++ {
+		outputs := make([]*Tensor, directions)
+		for d := 0; d < directions; d++ {
+			index := l*directions + d
+			h0, err := initialState(initialHidden, index, stateSize)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			var c0 *Tensor
+			if layer.Cell == LSTMCell {
+				c0, err = initialState(initialCell, index, stateSize)
+				if err != nil {
+					return nil, nil, err
+				}
+			}
+
+			result := layer.direction(input, layer.Weights[index], h0, c0, d == 1, batchSize, steps)
+			outputs[d], err = Narrow(result, 0, 0, steps*stateSize)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			outputs[d].Reshape(batchSize, steps, layer.HiddenSize)
+			hidden, err := Narrow(result, 0, steps*stateSize, stateSize)
+			if err != nil {
+				return nil, nil, err
+			}
+
+			hiddens = append(hiddens, hidden)
+			if layer.Cell == LSTMCell {
+				cell, err := Narrow(result, 0, (steps+1)*stateSize, stateSize)
+				if err != nil {
+					return nil, nil, err
+				}
+
+				cells = append(cells, cell)
+			}
+		}
+
+		input = outputs[0]
+		if directions > 1 {
+			var err error
+			input, err = Concat(outputs, 2)
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	state := &RecurrentState{}
+	var err error
+	state.Hidden, err = Stack(hiddens)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	state.Hidden.Reshape(stateDims...)
+	if layer.Cell == LSTMCell {
+		state.Cell, err = Stack(cells)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		state.Cell.Reshape(stateDims...)
+	}
+
+	if !batched {
+		input.Reshape(steps, directions*layer.HiddenSize)
+	}
+
+	return input, state, nil
+}
+
+// Stateful layers carry the previous final state into the next call, detached so backpropagation is
+// truncated at chunk boundaries
+func (layer *recurrentLayer) Execute(tensor *Tensor) (*Tensor, error) {
+	var initial *RecurrentState
+	if layer.Stateful && layer.State != nil {
+		initial = layer.State.Detach()
+	}
+
+	output, state, err := layer.Forward(tensor, initial)
+	if err != nil {
+		return nil, err
+	}
+
+	layer.State = state
+	return output, nil
+}
+
+type RNNLayer struct {
+	recurrentLayer
+}
+
+func NewRNNLayer(context *NeuralContext, inputSize int, hiddenSize int, layers int, bidirectional bool, useBias bool, useRelu bool) *RNNLayer {
+	cell := RNNTanhCell
+	if useRelu {
+		cell = RNNReluCell
+	}
+
+	return &RNNLayer{newRecurrentLayer(context, cell, inputSize, hiddenSize, layers, bidirectional, useBias)}
+}
+
+type LSTMLayer struct {
+	recurrentLayer
+}
+
+func NewLSTMLayer(context *NeuralContext, inputSize int, hiddenSize int, layers int, bidirectional bool, useBias bool) *LSTMLayer {
+	return &LSTMLayer{newRecurrentLayer(context, LSTMCell, inputSize, hiddenSize, layers, bidirectional, useBias)}
+}
+
+type GRULayer struct {
+	recurrentLayer
+}
+
+func NewGRULayer(context *NeuralContext, inputSize int, hiddenSize int, layers int, bidirectional bool, useBias bool) *GRULayer {
+	return &GRULayer{newRecurrentLayer(context, GRUCell, inputSize, hiddenSize, layers, bidirectional, useBias)}
+}
@@ -0,0 +1,74 @@
+package nn
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestRecurrentGradients(t *testing.T) {
+	context := NewNeuralContext(0)
+	cases := []struct {
+		name  string
+		layer *recurrentLayer
+	}{
+		{"rnn tanh", &NewRNNLayer(context, 2, 3, 2, true, true, false).recurrentLayer},
+		{"rnn relu", &NewRNNLayer(context, 2, 3, 1, false, false, true).recurrentLayer},
+		{"lstm", &NewLSTMLayer(context, 2, 3, 2, true, true).recurrentLayer},
+		{"gru", &NewGRULayer(context, 2, 3, 2, true, true).recurrentLayer},
+	}
+
+	for _, c := range cases {
+		random := rand.New(rand.NewSource(1))
+		input := randomTensor(random, 2, 3, 2)
+		directions := c.layer.directions()
+		initial := &RecurrentState{Hidden: randomTensor(random, c.layer.Layers*directions, 2, 3)}
+		parameters := []*Tensor{input, initial.Hidden}
+		if c.layer.Cell == LSTMCell {
+			initial.Cell = randomTensor(random, c.layer.Layers*directions, 2, 3)
+			parameters = append(parameters, initial.Cell)
+		}
+
+		for _, weights := range c.layer.Weights {
+			parameters = append(parameters, weights.tensors()...)
+		}
+
+		checkGradients(t, c.name, parameters, func() (*Tensor, error) {
+			output, _, err := c.layer.Forward(input, initial)
+			return output, err
+		})
+
+		checkGradients(t, c.name+" final state", parameters, func() (*Tensor, error) {
+			_, state, err := c.layer.Forward(input, initial)
+			if err != nil || state.Cell == nil {
+				return state.Hidden, err
+			}
+
+			return Concat([]*Tensor{state.Hidden, state.Cell}, 0)
+		})
+	}
+}
+
+func TestStatefulRecurrentTruncatesBackpropagation(t *testing.T) {
+	layer := NewLSTMLayer(NewNeuralContext(0), 2, 3, 1, false, true)
+	layer.Stateful = true
+	random := rand.New(rand.NewSource(1))
+	first, second := randomTensor(random, 1, 4, 2), randomTensor(random, 1, 4, 2)
+	if _, err := layer.Execute(first); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := layer.Execute(second)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output.Sum().Backward()
+	if slices.ContainsFunc(first.Gradients, func(value float64) bool { return value != 0 }) {
+		t.Fatalf("expected no gradient to reach the previous chunk, got %v", first.Gradients)
+	}
+
+	if !slices.ContainsFunc(second.Gradients, func(value float64) bool { return value != 0 }) {
+		t.Fatal("expected the current chunk to receive gradient")
+	}
+}
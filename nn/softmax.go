@@ -1,5 +1,10 @@
 package nn
 
+import (
+	"math"
+	"slices"
+)
+
 type SoftmaxLayer struct {
 	context *NeuralContext
 }
@@ -14,6 +19,64 @@ func (layer *SoftmaxLayer) Zerograd() {
 func (layer *SoftmaxLayer) UpdateParameters(updateCallback UpdateTensorFunction) {
 }
 
+func (layer *SoftmaxLayer) SetTraining(training bool) {
+}
+
 func (layer *SoftmaxLayer) Execute(tensor *Tensor) (*Tensor, error) {
 	return tensor.Softmax()
 }
+
+func StableSoftmax(tensor *Tensor) *Tensor {
+	return MaskedSoftmax(tensor, 1, nil)
+}
+
+// Softmax of scale*x over the last axis, positions where masked returns true get zero probability and a
+// fully masked row stays all zero instead of turning into NaN
+func MaskedSoftmax(tensor *Tensor, scale float64, masked func(index int) bool) *Tensor {
+	shape := slices.Clone(tensor.Shape())
+	width := shape[len(shape)-1]
+	values := tensor.Backing.Backing
+	result := make([]float64, len(values))
+	for start := 0; start < len(values); start += width {
+		largest := math.Inf(-1)
+		for i := start; i < start+width; i++ {
+			if masked == nil || !masked(i) {
+				largest = math.Max(largest, scale*values[i])
+			}
+		}
+
+		if math.IsInf(largest, -1) {
+			continue
+		}
+
+		sum := 0.0
+		for i := start; i < start+width; i++ {
+			if masked == nil || !masked(i) {
+				result[i] = math.Exp(scale*values[i] - largest)
+				sum += result[i]
+			}
+		}
+
+		for i := start; i < start+width; i++ {
+			result[i] /= sum
+		}
+	}
+
+	return &Tensor{
+		Backing:   NewNArray(result).Reshape(shape...),
+		Gradients: make([]float64, len(result)),
+		Children:  []*Tensor{tensor},
+		backward: func(parent *Tensor) {
+			for start := 0; start < len(result); start += width {
+				dot := 0.0
+				for i := start; i < start+width; i++ {
+					dot += result[i] * parent.Gradients[i]
+				}
+
+				for i := start; i < start+width; i++ {
+					tensor.Gradients[i] += scale * result[i] * (parent.Gradients[i] - dot)
+				}
+			}
+		},
+	}
+}
@@ -0,0 +1,154 @@
+package nn
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+func StackBackward(parent *Tensor) {
+	offset := 0
+	for _, child := range parent.Children {
+		for i := range child.Gradients {
+			child.Gradients[i] += parent.Gradients[offset+i]
+		}
+
+		offset += len(child.Gradients)
+	}
+}
+
+func Stack(tensors []*Tensor) (*Tensor, error) {
+	if len(tensors) == 0 {
+		return nil, errors.New("cannot stack an empty list of tensors")
+	}
+
+	shape := tensors[0].Shape()
+	backing := make([]float64, 0, len(tensors)*len(tensors[0].Backing.Backing))
+	for _, tensor := range tensors {
+		if !slices.Equal(tensor.Shape(), shape) {
+			return nil, fmt.Errorf("cannot stack tensor of shape %v with tensor of shape %v", tensor.Shape(), shape)
+		}
+
+		backing = append(backing, tensor.Backing.Backing...)
+	}
+
+	result := NewTensorFromArray(backing).Reshape(append([]int{len(tensors)}, shape...)...)
+	result.Children = tensors
+	result.backward = StackBackward
+
+	return result, nil
+}
+
+// Splits a shape around axis into the element counts before it and after it
+func axisBlocks(shape []int, axis int) (int, int) {
+	outer, inner := 1, 1
+	for _, dim := range shape[:axis] {
+		outer *= dim
+	}
+
+	for _, dim := range shape[axis+1:] {
+		inner *= dim
+	}
+
+	return outer, inner
+}
+
+func Concat(tensors []*Tensor, axis int) (*Tensor, error) {
+	if len(tensors) == 0 {
+		return nil, errors.New("cannot concatenate an empty list of tensors")
+	}
+
+	shape := slices.Clone(tensors[0].Shape())
+	if axis < 0 {
+		axis += len(shape)
+	}
+
+	if axis < 0 || axis >= len(shape) {
+		return nil, fmt.Errorf("concatenation axis %d is out of range for shape %v", axis, shape)
+	}
+
+	sizes := make([]int, len(tensors))
+	for i, tensor := range tensors {
+		other := tensor.Shape()
+		if len(other) != len(shape) || !slices.Equal(other[:axis], shape[:axis]) || !slices.Equal(other[axis+1:], shape[axis+1:]) {
+			return nil, fmt.Errorf("cannot concatenate tensor of shape %v with tensor of shape %v along axis %d", other, shape, axis)
+		}
+
+		sizes[i] = other[axis]
+	}
+
+	outer, inner := axisBlocks(shape, axis)
+	shape[axis] = 0
+	for _, size := range sizes {
+		shape[axis] += size
+	}
+
+	// Walks the output in the order of the concatenated blocks, calling back with the matching child range
+	forEach := func(callback func(child *Tensor, childOffset int, offset int, count int)) {
+		offset := 0
+		for o := 0; o < outer; o++ {
+			for i, tensor := range tensors {
+				count := sizes[i] * inner
+				callback(tensor, o*count, offset, count)
+				offset += count
+			}
+		}
+	}
+
+	result := make([]float64, 0, outer*shape[axis]*inner)
+	forEach(func(child *Tensor, childOffset int, offset int, count int) {
+		result = append(result, child.Backing.Backing[childOffset:childOffset+count]...)
+	})
+
+	return &Tensor{
+		Backing:   NewNArray(result).Reshape(shape...),
+		Gradients: make([]float64, len(result)),
+		Children:  tensors,
+		backward: func(parent *Tensor) {
+			forEach(func(child *Tensor, childOffset int, offset int, count int) {
+				for i := 0; i < count; i++ {
+					child.Gradients[childOffset+i] += parent.Gradients[offset+i]
+				}
+			})
+		},
+	}, nil
+}
+
+func Narrow(tensor *Tensor, axis int, start int, length int) (*Tensor, error) {
+	shape := slices.Clone(tensor.Shape())
+	if axis < 0 {
+		axis += len(shape)
+	}
+
+	if axis < 0 || axis >= len(shape) {
+		return nil, fmt.Errorf("narrow axis %d is out of range for shape %v", axis, shape)
+	}
+
+	if start < 0 || length < 0 || start+length > shape[axis] {
+		return nil, fmt.Errorf("narrow range [%d,%d) is out of bounds for axis %d of shape %v", start, start+length, axis, shape)
+	}
+
+	outer, inner := axisBlocks(shape, axis)
+	source := func(o int) int {
+		return (o*shape[axis] + start) * inner
+	}
+
+	result := make([]float64, 0, outer*length*inner)
+	for o := 0; o < outer; o++ {
+		result = append(result, tensor.Backing.Backing[source(o):source(o)+length*inner]...)
+	}
+
+	shape[axis] = length
+	return &Tensor{
+		Backing:   NewNArray(result).Reshape(shape...),
+		Gradients: make([]float64, len(result)),
+		Children:  []*Tensor{tensor},
+		backward: func(parent *Tensor) {
+			for o := 0; o < outer; o++ {
+				for i := 0; i < length*inner; i++ {
+					tensor.Gradients[source(o)+i] += parent.Gradients[o*length*inner+i]
+				}
+			}
+		},
+	}, nil
+}
@@ -40,6 +40,15 @@ func NewTensorRand(length int, rand *rand.Rand) *Tensor {
 	}
 }
 
+func NewTensorUniform(length int, low float64, high float64, rand *rand.Rand) *Tensor {
+	tensor := NewTensorEmpty(length)
+	for i := range tensor.Backing.Backing {
+		tensor.Backing.Backing[i] = low + rand.Float64()*(high-low)
+	}
+
+	return tensor
+}
+
 func NewTensorEmpty(length int) *Tensor {
 	return &Tensor{
 		Backing:   NewNArrayEmpty(length),
@@ -166,9 +175,17 @@ func LogBackward(parent *Tensor) {
 
 func ReluBackward(parent *Tensor) {
 	child := parent.Children[0]
+	derivative := func(value float64) float64 {
+		if value > 0 {
+			return 1
+		}
+
+		return 0
+	}
+
 	if parent.IsScalar() {
 		for i, _ := range child.Gradients {
-			child.Gradients[i] += math.Max(0, parent.Backing.Backing[0]) * parent.Gradients[0]
+			child.Gradients[i] += derivative(parent.Backing.Backing[0]) * parent.Gradients[0]
 		}
 	} else {
 		if !parent.TensorEqual(child) {
@@ -176,7 +193,7 @@ func ReluBackward(parent *Tensor) {
 		}
 
 		for i, _ := range child.Gradients {
-			child.Gradients[i] += math.Max(0, parent.Backing.Backing[i]) * parent.Gradients[i]
+			child.Gradients[i] += derivative(parent.Backing.Backing[i]) * parent.Gradients[i]
 		}
 	}
 }
@@ -198,11 +215,15 @@ func FromTensorsBackward(parent *Tensor) {
 		return
 	}
 
+	offset := 0
 	for i := range parent.Children {
 		child := parent.Children[i]
 		if len(parent.Gradients) > len(child.Gradients) {
-			count := len(parent.Gradients) / len(child.Gradients)
-			child.Gradients = parent.Gradients[i*count : i*count+count]
+			for j := range child.Gradients {
+				child.Gradients[j] += parent.Gradients[offset+j]
+			}
+
+			offset += len(child.Gradients)
 		} else {
 			child.Gradients = Fill(child.Gradients, parent.AvgGradient())
 		}
@@ -211,18 +232,17 @@ func FromTensorsBackward(parent *Tensor) {
 
 func TanhBackward(parent *Tensor) {
 	child := parent.Children[0]
-	coshX := Cosh(parent.Backing)
 	if parent.IsScalar() {
 		for i, _ := range child.Gradients {
-			child.Gradients[i] += (1 / (coshX.Backing[i] * coshX.Backing[i])) * parent.Gradients[0]
+			child.Gradients[i] += (1 - parent.Backing.Backing[0]*parent.Backing.Backing[0]) * parent.Gradients[0]
 		}
 	} else {
 		if !parent.TensorEqual(child) {
-			panic("ReluBackward: Expected child tensor to be equal to parent")
+			panic("TanhBackward: Expected child tensor to be equal to parent")
 		}
 
 		for i, _ := range child.Gradients {
-			child.Gradients[i] += (1 / (coshX.Backing[i] * coshX.Backing[i])) * parent.Gradients[i]
+			child.Gradients[i] += (1 - parent.Backing.Backing[i]*parent.Backing.Backing[i]) * parent.Gradients[i]
 		}
 	}
 }
@@ -272,6 +292,15 @@ func (t *Tensor) Reshape(dims ...int) *Tensor {
 	return t
 }
 
+// Copies the values into a new leaf tensor so gradients stop flowing into the graph that produced them
+func (t *Tensor) Detach() *Tensor {
+	return NewTensorFromArray(slices.Clone(t.Backing.Backing)).Reshape(slices.Clone(t.Shape())...)
+}
+
+func (t *Tensor) Shape() []int {
+	return t.Backing.Shape()
+}
+
 func (t *Tensor) IsScalar() bool {
 	return t.Backing.IsScalar()
 }
@@ -497,7 +526,7 @@ func (t *Tensor) Relu() *Tensor {
 
 	result.backward = ReluBackward
 	result.Children = []*Tensor{t}
-	return result
+	return result.Reshape(slices.Clone(t.Shape())...)
 }
 
 func (t *Tensor) Tanh() *Tensor {
@@ -509,5 +538,5 @@ func (t *Tensor) Tanh() *Tensor {
 
 	result.backward = TanhBackward
 	result.Children = []*Tensor{t}
-	return result
+	return result.Reshape(slices.Clone(t.Shape())...)
 }
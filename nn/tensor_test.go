@@ -0,0 +1,19 @@
+package nn
+
+import (
+	"math/rand"
+	"testing"
+)
+
+func TestFromTensorsGradients(t *testing.T) {
+	random := rand.New(rand.NewSource(1))
+	first, second := randomTensor(random, 2), randomTensor(random, 3)
+	checkGradients(t, "uneven children", []*Tensor{first, second}, func() (*Tensor, error) {
+		return NewFromTensors([]*Tensor{first, second}), nil
+	})
+
+	// A child listed twice has to collect the gradient of both positions
+	checkGradients(t, "repeated child", []*Tensor{first}, func() (*Tensor, error) {
+		return NewFromTensors([]*Tensor{first, first}), nil
+	})
+}
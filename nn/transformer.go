@@ -0,0 +1,480 @@
+package nn
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"slices"
+)
+
+type FeedForwardLayer struct {
+	WeightsInput  *Tensor
+	BiasInput     *Tensor
+	WeightsOutput *Tensor
+	BiasOutput    *Tensor
+	activation    ActivationFunction
+	dropout       *DropoutLayer
+	context       *NeuralContext
+}
+
+func NewFeedForwardLayer(context *NeuralContext, embedDim int, hiddenDim int, dropout float64, activation ActivationFunction) *FeedForwardLayer {
+	inputBound, outputBound := 1/math.Sqrt(float64(embedDim)), 1/math.Sqrt(float64(hiddenDim))
+	return &FeedForwardLayer{
+		WeightsInput:  NewTensorUniform(hiddenDim*embedDim, -inputBound, inputBound, context.Random).Reshape(hiddenDim, embedDim),
+		BiasInput:     NewTensorUniform(hiddenDim, -inputBound, inputBound, context.Random),
+		WeightsOutput: NewTensorUniform(embedDim*hiddenDim, -outputBound, outputBound, context.Random).Reshape(embedDim, hiddenDim),
+		BiasOutput:    NewTensorUniform(embedDim, -outputBound, outputBound, context.Random),
+		activation:    activation,
+		dropout:       NewDropoutLayer(context, dropout),
+		context:       context,
+	}
+}
+
+func (layer *FeedForwardLayer) parameters() []*Tensor {
+	return []*Tensor{layer.WeightsInput, layer.BiasInput, layer.WeightsOutput, layer.BiasOutput}
+}
+
+func (layer *FeedForwardLayer) Zerograd() {
+	for _, parameter := range layer.parameters() {
+		parameter.Zerograd()
+	}
+}
+
+func (layer *FeedForwardLayer) UpdateParameters(updateCallback UpdateTensorFunction) {
+	for _, parameter := range layer.parameters() {
+		updateCallback(layer.context, parameter)
+	}
+}
+
+func (layer *FeedForwardLayer) SetTraining(training bool) {
+	layer.dropout.SetTraining(training)
+}
+
+func (layer *FeedForwardLayer) Execute(tensor *Tensor) (*Tensor, error) {
+	hidden, err := Linear(tensor, layer.WeightsInput, layer.BiasInput)
+	if err != nil {
+		return nil, err
+	}
+
+	// Activations are not required to keep the input shape, the output projection needs [...,hidden]
+	shape := slices.Clone(hidden.Shape())
+	hidden, err = layer.activation(hidden)
+	if err != nil {
+		return nil, err
+	}
+
+	hidden, err = layer.dropout.Execute(hidden.Reshape(shape...))
+	if err != nil {
+		return nil, err
+	}
+
+	return Linear(hidden, layer.WeightsOutput, layer.BiasOutput)
+}
+
+// Wraps a sublayer with dropout, the residual connection and layer norm, normalizing the sublayer input
+// for pre-norm blocks and the residual sum for post-norm blocks
+func residualBlock(tensor *Tensor, norm *LayerNormLayer, dropout *DropoutLayer, normFirst bool, sublayer func(input *Tensor) (*Tensor, error)) (*Tensor, error) {
+	input := tensor
+	var err error
+	if normFirst {
+		input, err = norm.Execute(tensor)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	output, err := sublayer(input)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err = dropout.Execute(output)
+	if err != nil {
+		return nil, err
+	}
+
+	output, err = TensorAdd(tensor, output)
+	if err != nil {
+		return nil, err
+	}
+
+	output.Reshape(slices.Clone(tensor.Shape())...)
+	if normFirst {
+		return output, nil
+	}
+
+	return norm.Execute(output)
+}
+
+type TransformerEncoderLayer struct {
+	SelfAttention   *MultiHeadAttentionLayer
+	FeedForward     *FeedForwardLayer
+	AttentionNorm   *LayerNormLayer
+	FeedForwardNorm *LayerNormLayer
+	NormFirst       bool
+	dropouts        []*DropoutLayer
+	context         *NeuralContext
+}
+
+func NewTransformerEncoderLayer(context *NeuralContext, embedDim int, heads int, feedForwardDim int, dropout float64, activation ActivationFunction, normFirst bool, causal bool) *TransformerEncoderLayer {
+	return &TransformerEncoderLayer{
+		SelfAttention:   NewMultiHeadAttentionLayer(context, embedDim, heads, dropout, true, causal),
+		FeedForward:     NewFeedForwardLayer(context, embedDim, feedForwardDim, dropout, activation),
+		AttentionNorm:   NewLayerNormLayer(context, []int{embedDim}, 1e-5, true),
+		FeedForwardNorm: NewLayerNormLayer(context, []int{embedDim}, 1e-5, true),
+		NormFirst:       normFirst,
+		dropouts:        []*DropoutLayer{NewDropoutLayer(context, dropout), NewDropoutLayer(context, dropout)},
+		context:         context,
+	}
+}
+
+func (layer *TransformerEncoderLayer) sublayers() []ICallable {
+	return []ICallable{layer.SelfAttention, layer.FeedForward, layer.AttentionNorm, layer.FeedForwardNorm, layer.dropouts[0], layer.dropouts[1]}
+}
+
+func (layer *TransformerEncoderLayer) Zerograd() {
+	for _, sublayer := range layer.sublayers() {
+		sublayer.Zerograd()
+	}
+}
+
+func (layer *TransformerEncoderLayer) UpdateParameters(updateCallback UpdateTensorFunction) {
+	for _, sublayer := range layer.sublayers() {
+		sublayer.UpdateParameters(updateCallback)
+	}
+}
+
+func (layer *TransformerEncoderLayer) SetTraining(training bool) {
+	for _, sublayer := range layer.sublayers() {
+		sublayer.SetTraining(training)
+	}
+}
+
+// Padding mask is [B,T] with 1 for real tokens, see MultiHeadAttentionLayer.Forward
+func (layer *TransformerEncoderLayer) Forward(tensor *Tensor, paddingMask *Tensor) (*Tensor, error) {
+	result, err := residualBlock(tensor, layer.AttentionNorm, layer.dropouts[0], layer.NormFirst, func(input *Tensor) (*Tensor, error) {
+		output, _, err := layer.SelfAttention.Forward(input, input, input, paddingMask)
+		return output, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return residualBlock(result, layer.FeedForwardNorm, layer.dropouts[1], layer.NormFirst, layer.FeedForward.Execute)
+}
+
+func (layer *TransformerEncoderLayer) Execute(tensor *Tensor) (*Tensor, error) {
+	return layer.Forward(tensor, nil)
+}
+
+// Reads MainTensor with an optional MaskTensor padding mask
+func (layer *TransformerEncoderLayer) ExecuteMany(inputs NamedTensors) (NamedTensors, error) {
+	tensor, err := inputs.Require(MainTensor)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := layer.Forward(tensor, inputs[MaskTensor])
+	if err != nil {
+		return nil, err
+	}
+
+	return NamedTensors{MainTensor: result}, nil
+}
+
+type TransformerDecoderLayer struct {
+	SelfAttention   *MultiHeadAttentionLayer
+	CrossAttention  *MultiHeadAttentionLayer
+	FeedForward     *FeedForwardLayer
+	AttentionNorm   *LayerNormLayer
+	CrossNorm       *LayerNormLayer
+	FeedForwardNorm *LayerNormLayer
+	NormFirst       bool
+	Memory          *Tensor
+	dropouts        []*DropoutLayer
+	context         *NeuralContext
+}
+
+func NewTransformerDecoderLayer(context *NeuralContext, embedDim int, heads int, feedForwardDim int, dropout float64, activation ActivationFunction, normFirst bool) *TransformerDecoderLayer {
+	return &TransformerDecoderLayer{
+		SelfAttention:   NewMultiHeadAttentionLayer(context, embedDim, heads, dropout, true, true),
+		CrossAttention:  NewMultiHeadAttentionLayer(context, embedDim, heads, dropout, true, false),
+		FeedForward:     NewFeedForwardLayer(context, embedDim, feedForwardDim, dropout, activation),
+		AttentionNorm:   NewLayerNormLayer(context, []int{embedDim}, 1e-5, true),
+		CrossNorm:       NewLayerNormLayer(context, []int{embedDim}, 1e-5, true),
+		FeedForwardNorm: NewLayerNormLayer(context, []int{embedDim}, 1e-5, true),
+		NormFirst:       normFirst,
+		dropouts:        []*DropoutLayer{NewDropoutLayer(context, dropout), NewDropoutLayer(context, dropout), NewDropoutLayer(context, dropout)},
+		context:         context,
+	}
+}
+
+func (layer *TransformerDecoderLayer) sublayers() []ICallable {
+	return []ICallable{layer.SelfAttention, layer.CrossAttention, layer.FeedForward, layer.AttentionNorm, layer.CrossNorm, layer.FeedForwardNorm, layer.dropouts[0], layer.dropouts[1], layer.dropouts[2]}
+}
+
+func (layer *TransformerDecoderLayer) Zerograd() {
+	for _, sublayer := range layer.sublayers() {
+		sublayer.Zerograd()
+	}
+}
+
+func (layer *TransformerDecoderLayer) UpdateParameters(updateCallback UpdateTensorFunction) {
+	for _, sublayer := range layer.sublayers() {
+		sublayer.UpdateParameters(updateCallback)
+	}
+}
+
+func (layer *TransformerDecoderLayer) SetTraining(training bool) {
+	for _, sublayer := range layer.sublayers() {
+		sublayer.SetTraining(training)
+	}
+}
+
+// Causal self attention over the target followed by attention over the encoder memory
+func (layer *TransformerDecoderLayer) Forward(target *Tensor, memory *Tensor, targetPaddingMask *Tensor, memoryPaddingMask *Tensor) (*Tensor, error) {
+	result, err := residualBlock(target, layer.AttentionNorm, layer.dropouts[0], layer.NormFirst, func(input *Tensor) (*Tensor, error) {
+		output, _, err := layer.SelfAttention.Forward(input, input, input, targetPaddingMask)
+		return output, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	result, err = residualBlock(result, layer.CrossNorm, layer.dropouts[1], layer.NormFirst, func(input *Tensor) (*Tensor, error) {
+		output, _, err := layer.CrossAttention.Forward(input, memory, memory, memoryPaddingMask)
+		return output, err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return residualBlock(result, layer.FeedForwardNorm, layer.dropouts[2], layer.NormFirst, layer.FeedForward.Execute)
+}
+
+// Attends over Memory, which has to be set to the encoder output beforehand
+func (layer *TransformerDecoderLayer) Execute(tensor *Tensor) (*Tensor, error) {
+	if layer.Memory == nil {
+		return nil, errors.New("transformer decoder layer has no memory to attend to")
+	}
+
+	return layer.Forward(tensor, layer.Memory, nil, nil)
+}
+
+// Reads MainTensor as the target and MemoryTensor, falling back to Memory, with the optional MaskTensor
+// and MemoryMaskTensor padding masks
+func (layer *TransformerDecoderLayer) ExecuteMany(inputs NamedTensors) (NamedTensors, error) {
+	target, err := inputs.Require(MainTensor)
+	if err != nil {
+		return nil, err
+	}
+
+	memory, ok := inputs[MemoryTensor]
+	if !ok {
+		memory = layer.Memory
+	}
+
+	if memory == nil {
+		return nil, fmt.Errorf("missing %q input", MemoryTensor)
+	}
+
+	result, err := layer.Forward(target, memory, inputs[MaskTensor], inputs[MemoryMaskTensor])
+	if err != nil {
+		return nil, err
+	}
+
+	return NamedTensors{MainTensor: result}, nil
+}
+
+type TransformerEncoder struct {
+	Layers  []*TransformerEncoderLayer
+	Norm    *LayerNormLayer
+	context *NeuralContext
+}
+
+// Builds count independent layers, pre-norm stacks usually want the final norm
+func NewTransformerEncoder(context *NeuralContext, count int, build func() *TransformerEncoderLayer, finalNorm bool) *TransformerEncoder {
+	if count <= 0 {
+		panic(fmt.Sprintf("transformer encoder needs at least one layer, got %d", count))
+	}
+
+	encoder := &TransformerEncoder{context: context}
+	for i := 0; i < count; i++ {
+		encoder.Layers = append(encoder.Layers, build())
+	}
+
+	if finalNorm {
+		encoder.Norm = NewLayerNormLayer(context, []int{encoder.Layers[0].SelfAttention.EmbedDim}, 1e-5, true)
+	}
+
+	return encoder
+}
+
+func (encoder *TransformerEncoder) sublayers() []ICallable {
+	sublayers := make([]ICallable, 0, len(encoder.Layers)+1)
+	for _, layer := range encoder.Layers {
+		sublayers = append(sublayers, layer)
+	}
+
+	if encoder.Norm != nil {
+		sublayers = append(sublayers, encoder.Norm)
+	}
+
+	return sublayers
+}
+
+func (encoder *TransformerEncoder) Zerograd() {
+	for _, sublayer := range encoder.sublayers() {
+		sublayer.Zerograd()
+	}
+}
+
+func (encoder *TransformerEncoder) UpdateParameters(updateCallback UpdateTensorFunction) {
+	for _, sublayer := range encoder.sublayers() {
+		sublayer.UpdateParameters(updateCallback)
+	}
+}
+
+func (encoder *TransformerEncoder) SetTraining(training bool) {
+	for _, sublayer := range encoder.sublayers() {
+		sublayer.SetTraining(training)
+	}
+}
+
+func (encoder *TransformerEncoder) Forward(tensor *Tensor, paddingMask *Tensor) (*Tensor, error) {
+	result := tensor
+	var err error
+	for _, layer := range encoder.Layers {
+		result, err = layer.Forward(result, paddingMask)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if encoder.Norm != nil {
+		return encoder.Norm.Execute(result)
+	}
+
+	return result, nil
+}
+
+func (encoder *TransformerEncoder) Execute(tensor *Tensor) (*Tensor, error) {
+	return encoder.Forward(tensor, nil)
+}
+
+func (encoder *TransformerEncoder) ExecuteMany(inputs NamedTensors) (NamedTensors, error) {
+	tensor, err := inputs.Require(MainTensor)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := encoder.Forward(tensor, inputs[MaskTensor])
+	if err != nil {
+		return nil, err
+	}
+
+	return NamedTensors{MainTensor: result}, nil
+}
+
+type TransformerDecoder struct {
+	Layers  []*TransformerDecoderLayer
+	Norm    *LayerNormLayer
+	Memory  *Tensor
+	context *NeuralContext
+}
+
+func NewTransformerDecoder(context *NeuralContext, count int, build func() *TransformerDecoderLayer, finalNorm bool) *TransformerDecoder {
+	if count <= 0 {
+		panic(fmt.Sprintf("transformer decoder needs at least one layer, got %d", count))
+	}
+
+	decoder := &TransformerDecoder{context: context}
+	for i := 0; i < count; i++ {
+		decoder.Layers = append(decoder.Layers, build())
+	}
+
+	if finalNorm {
+		decoder.Norm = NewLayerNormLayer(context, []int{decoder.Layers[0].SelfAttention.EmbedDim}, 1e-5, true)
+	}
+
+	return decoder
+}
+
+func (decoder *TransformerDecoder) sublayers() []ICallable {
+	sublayers := make([]ICallable, 0, len(decoder.Layers)+1)
+	for _, layer := range decoder.Layers {
+		sublayers = append(sublayers, layer)
+	}
+
+	if decoder.Norm != nil {
+		sublayers = append(sublayers, decoder.Norm)
+	}
+
+	return sublayers
+}
+
+func (decoder *TransformerDecoder) Zerograd() {
+	for _, sublayer := range decoder.sublayers() {
+		sublayer.Zerograd()
+	}
+}
+
+func (decoder *TransformerDecoder) UpdateParameters(updateCallback UpdateTensorFunction) {
+	for _, sublayer := range decoder.sublayers() {
+		sublayer.UpdateParameters(updateCallback)
+	}
+}
+
+func (decoder *TransformerDecoder) SetTraining(training bool) {
+	for _, sublayer := range decoder.sublayers() {
+		sublayer.SetTraining(training)
+	}
+}
+
+func (decoder *TransformerDecoder) Forward(target *Tensor, memory *Tensor, targetPaddingMask *Tensor, memoryPaddingMask *Tensor) (*Tensor, error) {
+	result := target
+	var err error
+	for _, layer := range decoder.Layers {
+		result, err = layer.Forward(result, memory, targetPaddingMask, memoryPaddingMask)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if decoder.Norm != nil {
+		return decoder.Norm.Execute(result)
+	}
+
+	return result, nil
+}
+
+func (decoder *TransformerDecoder) Execute(tensor *Tensor) (*Tensor, error) {
+	if decoder.Memory == nil {
+		return nil, errors.New("transformer decoder has no memory to attend to")
+	}
+
+	return decoder.Forward(tensor, decoder.Memory, nil, nil)
+}
+
+func (decoder *TransformerDecoder) ExecuteMany(inputs NamedTensors) (NamedTensors, error) {
+	target, err := inputs.Require(MainTensor)
+	if err != nil {
+		return nil, err
+	}
+
+	memory, ok := inputs[MemoryTensor]
+	if !ok {
+		memory = decoder.Memory
+	}
+
+	if memory == nil {
+		return nil, fmt.Errorf("missing %q input", MemoryTensor)
+	}
+
+	result, err := decoder.Forward(target, memory, inputs[MaskTensor], inputs[MemoryMaskTensor])
+	if err != nil {
+		return nil, err
+	}
+
+	return NamedTensors{MainTensor: result}, nil
+}
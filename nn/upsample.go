@@ -0,0 +1,116 @@
+package nn
+
+import (
+	"fmt"
+	"math"
+)
+
+type UpsampleMode int
+
+const (
+	NearestUpsample UpsampleMode = iota
+	BilinearUpsample
+)
+
+type interpolation struct {
+	lower  int
+	upper  int
+	weight float64
+}
+
+func interpolationTable(input int, output int, mode UpsampleMode) []interpolation {
+	scale := float64(input) / float64(output)
+	table := make([]interpolation, output)
+	for i := range table {
+		if mode == NearestUpsample {
+			source := min(int(math.Floor(float64(i)*scale)), input-1)
+			table[i] = interpolation{lower: source, upper: source}
+			continue
+		}
+
+		source := math.Max((float64(i)+0.5)*scale-0.5, 0)
+		lower := min(int(math.Floor(source)), input-1)
+		table[i] = interpolation{
+			lower:  lower,
+			upper:  min(lower+1, input-1),
+			weight: source - float64(lower),
+		}
+	}
+
+	return table
+}
+
+type UpsampleLayer struct {
+	ScaleFactor float64
+	Mode        UpsampleMode
+	context     *NeuralContext
+}
+
+func NewUpsampleLayer(context *NeuralContext, scaleFactor float64, mode UpsampleMode) *UpsampleLayer {
+	return &UpsampleLayer{
+		ScaleFactor: scaleFactor,
+		Mode:        mode,
+		context:     context,
+	}
+}
+
+func (layer *UpsampleLayer) Zerograd() {
+}
+
+func (layer *UpsampleLayer) UpdateParameters(updateCallback UpdateTensorFunction) {
+}
+
+func (layer *UpsampleLayer) SetTraining(training bool) {
+}
+
+func (layer *UpsampleLayer) Execute(tensor *Tensor) (*Tensor, error) {
+	shape := tensor.Shape()
+	if len(shape) != 3 && len(shape) != 4 {
+		return nil, fmt.Errorf("upsample expected input of shape [B,C,H,W] or [C,H,W], got %v", shape)
+	}
+
+	height, width := shape[len(shape)-2], shape[len(shape)-1]
+	planes := len(tensor.Backing.Backing) / (height * width)
+	outHeight := int(math.Floor(float64(height) * layer.ScaleFactor))
+	outWidth := int(math.Floor(float64(width) * layer.ScaleFactor))
+	if outHeight <= 0 || outWidth <= 0 {
+		return nil, fmt.Errorf("upsample by %v of %v produces an empty output", layer.ScaleFactor, shape)
+	}
+
+	rows := interpolationTable(height, outHeight, layer.Mode)
+	columns := interpolationTable(width, outWidth, layer.Mode)
+	// Each output pixel blends four input pixels, nearest mode simply puts all weight on the first one
+	forEach := func(callback func(output int, input int, weight float64)) {
+		for plane := 0; plane < planes; plane++ {
+			for y, row := range rows {
+				for x, column := range columns {
+					output := (plane*outHeight+y)*outWidth + x
+					base := plane * height * width
+					callback(output, base+row.lower*width+column.lower, (1-row.weight)*(1-column.weight))
+					callback(output, base+row.lower*width+column.upper, (1-row.weight)*column.weight)
+					callback(output, base+row.upper*width+column.lower, row.weight*(1-column.weight))
+					callback(output, base+row.upper*width+column.upper, row.weight*column.weight)
+				}
+			}
+		}
+	}
+
+	result := make([]float64, planes*outHeight*outWidth)
+	forEach(func(output int, input int, weight float64) {
+		result[output] += weight * tensor.Backing.Backing[input]
+	})
+
+	outputDims := append([]int{}, shape...)
+	outputDims[len(shape)-2], outputDims[len(shape)-1] = outHeight, outWidth
+
+	return &Tensor{
+		Backing:   NewNArray(result).Reshape(outputDims...),
+		Gradients: make([]float64, len(result)),
+		Children:  []*Tensor{tensor},
+		backward: func(parent *Tensor) {
+			forEach(func(output int, input int, weight float64) {
+				tensor.Gradients[input] += weight * parent.Gradients[output]
+			})
+		},
+	}, nil
+}
@@ -2,7 +2,7 @@ package nn
 
 import (
 	"math/rand"
-	"sort"
+	"slices"
 
 	"github.com/Lyx52/micrograd-in-go.git/types"
 )
@@ -71,14 +71,14 @@ func OneHotEncodeAny(labels []any) map[string][]float64 {
 }
 
 func OneHotEncode(labels []string) map[string][]float64 {
-	sort.Strings(labels)
+	unique := slices.Clone(labels)
+	slices.Sort(unique)
+	unique = slices.Compact(unique)
 	mapping := make(map[string][]float64)
-	step := 0
 
-	for i := 0; i < len(labels); i++ {
-		mapping[labels[i]] = make([]float64, len(labels))
-		mapping[labels[i]][step] = 1.0
-		step++
+	for i := 0; i < len(unique); i++ {
+		mapping[unique[i]] = make([]float64, len(unique))
+		mapping[unique[i]][i] = 1.0
 	}
 
 	return mapping
@@ -0,0 +1,110 @@
+package tests
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"time"
+
+	"github.com/Lyx52/micrograd-in-go.git/data"
+	"github.com/Lyx52/micrograd-in-go.git/nn"
+)
+
+func Test_CharGPT(filePath string, useRandom bool) {
+	text, err := os.ReadFile(filePath)
+	if err != nil {
+		panic(err)
+	}
+
+	var context *nn.NeuralContext
+	if useRandom {
+		context = nn.NewNeuralContext(time.Now().UnixMilli())
+	} else {
+		context = nn.NewNeuralContext(0)
+	}
+
+	blockSize := 32
+	embedDim := 32
+	tokenizer := data.NewCharTokenizer(string(text))
+	vocabularySize := tokenizer.Vocabulary().Len()
+	dataset := data.NewSlidingWindowDataset(tokenizer.Encode(string(text)), blockSize, blockSize)
+	loader := data.NewDataLoader(context, dataset, 16, true, true)
+
+	model := nn.NewModule(context,
+		nn.NewEmbeddingLayer(context, vocabularySize, embedDim, nn.NoPadding),
+		nn.NewSinusoidalPositionalEncoding(context, blockSize, embedDim),
+		nn.NewTransformerEncoder(context, 2, func() *nn.TransformerEncoderLayer {
+			return nn.NewTransformerEncoderLayer(context, embedDim, 4, 4*embedDim, 0.1, nn.GeluActivation, true, true)
+		}, true),
+		nn.NewBatchedLinearLayer(context, embedDim, vocabularySize, true, nn.NoneActivation),
+	)
+
+	learningRate := 0.1
+	epochs := 5
+	step := 0
+	for epoch, batches := range loader.Epochs(epochs) {
+		for batch := range batches {
+			model.UpdateParameters(func(context *nn.NeuralContext, tensor *nn.Tensor) {
+				for j := range tensor.Backing.Backing {
+					tensor.Backing.Backing[j] += -learningRate * tensor.Gradients[j]
+				}
+			})
+
+			model.Zerograd()
+
+			logits, err := model.Execute(batch.X)
+			if err != nil {
+				panic(err)
+			}
+
+			loss, err := nn.SoftmaxCrossEntropy(logits, batch.Y, -1)
+			if err != nil {
+				panic(err)
+			}
+
+			loss.Backward()
+			fmt.Println(fmt.Sprintf("[Epoch %d/%d Step %d] Loss: %f", epoch, epochs, step, loss.Backing.Scalar()))
+			step++
+		}
+	}
+
+	if err = loader.Err(); err != nil {
+		panic(err)
+	}
+
+	// Sample a continuation one character at a time from the last position
+	model.Eval()
+	ids := tokenizer.Encode(string(text[:1]))
+	for len(ids) < 200 {
+		window := ids[max(0, len(ids)-blockSize):]
+		logits, err := model.Execute(data.IdTensor(window))
+		if err != nil {
+			panic(err)
+		}
+
+		last := logits.Backing.Backing[(len(window)-1)*vocabularySize:]
+		largest := math.Inf(-1)
+		for _, value := range last {
+			largest = math.Max(largest, value)
+		}
+
+		sum := 0.0
+		for _, value := range last {
+			sum += math.Exp(value - largest)
+		}
+
+		threshold := context.Random.Float64() * sum
+		next := vocabularySize - 1
+		for id, value := range last {
+			threshold -= math.Exp(value - largest)
+			if threshold <= 0 {
+				next = id
+				break
+			}
+		}
+
+		ids = append(ids, next)
+	}
+
+	fmt.Println(tokenizer.Decode(ids))
+}
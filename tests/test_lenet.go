@@ -0,0 +1,94 @@
+package tests
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/Lyx52/micrograd-in-go.git/data"
+	"github.com/Lyx52/micrograd-in-go.git/nn"
+	"github.com/Lyx52/micrograd-in-go.git/types"
+)
+
+func Test_LeNet(useRandom bool) {
+	var err error
+	_, labels, err := data.ReadIdx[uint8]("./public/train-labels.bin")
+	if err != nil {
+		panic(err)
+	}
+
+	dims, images, err := data.ReadIdx[uint8]("./public/train-images.bin")
+	if err != nil {
+		panic(err)
+	}
+
+	// Prep labels
+	ys, err := data.FitOrderedLabels(labels).OneHotAll(labels)
+	if err != nil {
+		panic(err)
+	}
+
+	// Prep images as single channel [1,H,W] tensors
+	imageSize := dims[1] * dims[2]
+	casted := types.CastNumber[float64, uint8](images, func(input uint8) float64 {
+		return float64(input) / 255
+	})
+	chunked := slices.Chunk[[]float64, float64](casted, imageSize)
+	xs := types.MapIter[*nn.Tensor, []float64](chunked, func(value []float64, i int) *nn.Tensor {
+		return nn.NewTensorFromArray(value).Reshape(1, dims[1], dims[2])
+	})
+
+	var context *nn.NeuralContext
+	if useRandom {
+		context = nn.NewNeuralContext(time.Now().UnixMilli())
+	} else {
+		context = nn.NewNeuralContext(0)
+	}
+
+	conv1, err := nn.NewConv2DLayer(context, 1, 6, 5, 1, 2, 1, 1, true, nn.ZeroPadding)
+	if err != nil {
+		panic(err)
+	}
+
+	conv2, err := nn.NewConv2DLayer(context, 6, 16, 5, 1, 0, 1, 1, true, nn.ZeroPadding)
+	if err != nil {
+		panic(err)
+	}
+
+	pool, err := nn.NewMaxPool2DLayer(context, 2, 2, 0)
+	if err != nil {
+		panic(err)
+	}
+
+	module := nn.NewModule(context,
+		conv1,
+		pool,
+		conv2,
+		pool,
+		nn.NewFlattenLayer(context),
+		nn.NewLinearLayer(context, 16*5*5, 120, true, nn.ReluActivation),
+		nn.NewLinearLayer(context, 120, 84, true, nn.TanhActivation),
+		nn.NewLinearLayer(context, 84, 10, true, nn.NoneActivation),
+		nn.NewSoftmaxLayer(context),
+	)
+
+	learningRate := 0.05
+	steps := 1000
+
+	for i := 0; i < steps; i++ {
+		module.UpdateParameters(func(context *nn.NeuralContext, tensor *nn.Tensor) {
+			for j := range tensor.Backing.Backing {
+				tensor.Backing.Backing[j] += -learningRate * tensor.Gradients[j]
+			}
+		})
+
+		module.Zerograd()
+
+		loss, err := nn.CrossEntropyLoss(module, ys, xs, 10)
+		if err != nil {
+			panic(err)
+		}
+		loss.Backward()
+		fmt.Println(fmt.Sprintf("[Step %d/%d] Loss: %f", i, steps, loss.Backing.Scalar()))
+	}
+}
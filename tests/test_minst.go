@@ -23,12 +23,10 @@ func Test_Minst(useRandom bool) {
 	}
 
 	// Prep labels
-	labelSet := data.NewSet[uint8]()
-	labelSet.AddAll(labels)
-	encoded := nn.OneHotEncode(slices.Collect[string](labelSet.Keys()))
-	ys := types.MapSlice[*nn.Tensor, uint8](labels, func(value uint8, i int) *nn.Tensor {
-		return nn.NewTensorFromArray(encoded[fmt.Sprint(value)])
-	})
+	ys, err := data.FitOrderedLabels(labels).OneHotAll(labels)
+	if err != nil {
+		panic(err)
+	}
 
 	// Prep images
 	imageSize := dims[1] * dims[2]